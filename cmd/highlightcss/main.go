@@ -0,0 +1,33 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"prosamik-backend/internal/parser"
+)
+
+// Generates the stylesheet for server-side highlighted code blocks:
+//
+//	go run ./cmd/highlightcss static/css/highlight.css
+func main() {
+	if len(os.Args) != 2 {
+		fmt.Println("Usage: highlightcss <output file>")
+		os.Exit(1)
+	}
+
+	file, err := os.Create(os.Args[1])
+	if err != nil {
+		fmt.Printf("Error creating stylesheet: %v\n", err)
+		os.Exit(1)
+	}
+	defer func() {
+		if cerr := file.Close(); cerr != nil {
+			fmt.Printf("Warning: failed to close stylesheet: %v\n", cerr)
+		}
+	}()
+
+	if err := parser.WriteHighlightCSS(file); err != nil {
+		fmt.Printf("Error writing stylesheet: %v\n", err)
+		os.Exit(1)
+	}
+}
@@ -8,7 +8,9 @@ import (
 	"os"
 	"prosamik-backend/internal/cache"
 	"prosamik-backend/internal/database"
+	"prosamik-backend/internal/handler"
 	"prosamik-backend/internal/router"
+	"prosamik-backend/internal/warmer"
 )
 
 func main() {
@@ -34,6 +36,9 @@ func main() {
 		log.Fatal(err)
 	}
 
+	// Keep the documents of registered blogs and projects cached
+	warmer.Start(handler.WarmDocument)
+
 	// Start server
 	port := ":10000"
 	fmt.Printf("Server starting on port %s\n", port)
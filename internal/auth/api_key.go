@@ -0,0 +1,32 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+)
+
+const (
+	apiKeyPrefix     = "pk_" // Marks the keys issued by the dashboard
+	apiKeyBytes      = 24    // Random bytes of a key
+	apiKeyShownChars = 8     // Characters of the key kept in the clear to tell keys apart
+)
+
+// GenerateAPIKey returns a new random API key together with its display prefix and hash
+func GenerateAPIKey() (key, prefix, hash string, err error) {
+	random := make([]byte, apiKeyBytes)
+	if _, err := rand.Read(random); err != nil {
+		return "", "", "", fmt.Errorf("generating API key: %w", err)
+	}
+
+	key = apiKeyPrefix + hex.EncodeToString(random)
+	return key, key[:len(apiKeyPrefix)+apiKeyShownChars], HashAPIKey(key), nil
+}
+
+// HashAPIKey returns the SHA-256 hash API keys are stored and looked up by.
+// Keys are long and random, so a fast hash is enough.
+func HashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
@@ -10,3 +10,13 @@ func GetGitHubToken() string {
 	token := os.Getenv("GITHUB_TOKEN")
 	return strings.TrimSpace(token) // Removes extra spaces and newlines
 }
+
+// GetGitLabToken retrieves the optional GitLab access token
+func GetGitLabToken() string {
+	return strings.TrimSpace(os.Getenv("GITLAB_TOKEN"))
+}
+
+// GetGiteaToken retrieves the optional Gitea access token
+func GetGiteaToken() string {
+	return strings.TrimSpace(os.Getenv("GITEA_TOKEN"))
+}
@@ -0,0 +1,85 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+// DocumentIndexEntry records a cached document under its repository so that
+// it can be invalidated when the repository changes
+type DocumentIndexEntry struct {
+	Key    string `json:"key"`    // Cache key of the document
+	URL    string `json:"url"`    // Document URL the entry was requested with
+	Format string `json:"format"` // Format the document is cached in
+	Branch string `json:"branch"` // Branch the document was read from
+	Path   string `json:"path"`   // File path of the document in the repository
+}
+
+// documentIndexKey returns the key of the set indexing the documents of a repository
+func documentIndexKey(repository string) string {
+	return "md:index:" + repository
+}
+
+// IndexDocument adds a cached document to the index of its repository.
+// The index lives as long as the longest-lived document in it.
+func IndexDocument(ctx context.Context, repository string, entry DocumentIndexEntry) error {
+	member, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("marshaling index entry: %w", err)
+	}
+
+	key := documentIndexKey(repository)
+	pipe := RedisClient.TxPipeline()
+	pipe.SAdd(ctx, key, member)
+	pipe.Expire(ctx, key, TTL+StaleTTL)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("writing document index: %w", err)
+	}
+	return nil
+}
+
+// IndexedDocuments returns the cached documents indexed for a repository
+func IndexedDocuments(ctx context.Context, repository string) ([]DocumentIndexEntry, error) {
+	members, err := RedisClient.SMembers(ctx, documentIndexKey(repository)).Result()
+	if err != nil {
+		return nil, fmt.Errorf("reading document index: %w", err)
+	}
+
+	entries := make([]DocumentIndexEntry, 0, len(members))
+	for _, member := range members {
+		var entry DocumentIndexEntry
+		if err := json.Unmarshal([]byte(member), &entry); err != nil {
+			fmt.Printf("Warning: skipping invalid document index entry: %v\n", err)
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+// InvalidateDocuments deletes cached documents and removes them from the index of their repository
+func InvalidateDocuments(ctx context.Context, repository string, entries []DocumentIndexEntry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+
+	keys := make([]string, 0, len(entries))
+	members := make([]interface{}, 0, len(entries))
+	for _, entry := range entries {
+		member, err := json.Marshal(entry)
+		if err != nil {
+			return fmt.Errorf("marshaling index entry: %w", err)
+		}
+		keys = append(keys, entry.Key)
+		members = append(members, member)
+	}
+
+	pipe := RedisClient.TxPipeline()
+	pipe.Del(ctx, keys...)
+	pipe.SRem(ctx, documentIndexKey(repository), members...)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("invalidating documents: %w", err)
+	}
+	return nil
+}
@@ -14,13 +14,34 @@ import (
 var (
 	RedisClient *redis.Client
 	TTL         = 1 * time.Hour
-	ErrNilCache = errors.New("nil cache content")
+	StaleTTL    = 24 * time.Hour      // How long documents may be served stale while they are revalidated
+	PinnedTTL   = 30 * 24 * time.Hour // How long documents rendered at a pinned ref are kept
+	PartialTTL  = 5 * time.Minute     // How soon documents missing some metadata are revalidated
+
+	// LastKnownGoodTTL is how long the last good copy of a document is kept to be served when its source fails
+	LastKnownGoodTTL = 30 * 24 * time.Hour
+	ErrNilCache      = errors.New("nil cache content")
 )
 
 // CachedContent represents the structure of cached data
 type CachedContent struct {
-	Content     string    `json:"content"`
-	LastUpdated time.Time `json:"last_updated"`
+	Content      string    `json:"content"`
+	LastUpdated  time.Time `json:"last_updated"`
+	ETag         string    `json:"etag,omitempty"`          // Upstream ETag of the cached document
+	LastModified string    `json:"last_modified,omitempty"` // Upstream Last-Modified of the cached document
+	FetchedAt    time.Time `json:"fetched_at,omitempty"`    // When the document was last fetched or revalidated
+	Pinned       bool      `json:"pinned,omitempty"`        // Rendered at an immutable ref, so it never goes stale
+	Partial      bool      `json:"partial,omitempty"`       // Rendered without some of its metadata
+}
+
+// IsStale reports whether a cached document is older than TTL, or PartialTTL for partial
+// documents, and should be revalidated
+func (c *CachedContent) IsStale() bool {
+	ttl := TTL
+	if c.Partial {
+		ttl = PartialTTL
+	}
+	return !c.Pinned && !c.FetchedAt.IsZero() && time.Since(c.FetchedAt) > ttl
 }
 
 // InitRedis initializes the Redis connection
@@ -160,6 +181,11 @@ func GetCachedContent(ctx context.Context, key string) (*CachedContent, error) {
 
 // SetCachedContent stores content in Redis
 func SetCachedContent(ctx context.Context, key string, content *CachedContent) error {
+	return SetCachedContentFor(ctx, key, content, TTL)
+}
+
+// SetCachedContentFor stores content in Redis that expires after ttl
+func SetCachedContentFor(ctx context.Context, key string, content *CachedContent, ttl time.Duration) error {
 	if content == nil {
 		return errors.New("nil content provided")
 	}
@@ -169,13 +195,70 @@ func SetCachedContent(ctx context.Context, key string, content *CachedContent) e
 		return fmt.Errorf("marshaling content: %w", err)
 	}
 
-	if err := RedisClient.Set(ctx, key, data, TTL).Err(); err != nil {
+	if err := RedisClient.Set(ctx, key, data, ttl).Err(); err != nil {
 		return fmt.Errorf("writing to Redis: %w", err)
 	}
 
 	return nil
 }
 
+// SetCachedDocument stores a revalidatable document in Redis.
+// The entry outlives TTL by StaleTTL so that it can be served stale while it is refreshed;
+// pinned documents never change and are kept for PinnedTTL.
+func SetCachedDocument(ctx context.Context, key string, content *CachedContent) error {
+	if content == nil {
+		return errors.New("nil content provided")
+	}
+	if content.FetchedAt.IsZero() {
+		content.FetchedAt = time.Now()
+	}
+
+	data, err := json.Marshal(content)
+	if err != nil {
+		return fmt.Errorf("marshaling content: %w", err)
+	}
+
+	ttl := TTL + StaleTTL
+	if content.Pinned {
+		ttl = PinnedTTL
+	}
+
+	if err := RedisClient.Set(ctx, key, data, ttl).Err(); err != nil {
+		return fmt.Errorf("writing to Redis: %w", err)
+	}
+
+	return nil
+}
+
+// lastKnownGoodKey returns the key of the last good copy of the document cached under key
+func lastKnownGoodKey(key string) string {
+	return "lkg:" + key
+}
+
+// SetLastKnownGood keeps a copy of a document for LastKnownGoodTTL. Unlike the document itself it
+// is not invalidated by webhooks, so that it can stand in whenever the source cannot be reached.
+func SetLastKnownGood(ctx context.Context, key string, content *CachedContent) error {
+	if content == nil {
+		return errors.New("nil content provided")
+	}
+
+	data, err := json.Marshal(content)
+	if err != nil {
+		return fmt.Errorf("marshaling content: %w", err)
+	}
+
+	if err := RedisClient.Set(ctx, lastKnownGoodKey(key), data, LastKnownGoodTTL).Err(); err != nil {
+		return fmt.Errorf("writing to Redis: %w", err)
+	}
+
+	return nil
+}
+
+// GetLastKnownGood retrieves the last good copy of the document cached under key
+func GetLastKnownGood(ctx context.Context, key string) (*CachedContent, error) {
+	return GetCachedContent(ctx, lastKnownGoodKey(key))
+}
+
 // GetCacheStats returns basic statistics about the Redis cache
 func GetCacheStats(ctx context.Context) (map[string]interface{}, error) {
 	stats := make(map[string]interface{})
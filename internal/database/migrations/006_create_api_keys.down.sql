@@ -0,0 +1,3 @@
+-- Drop the API key tables
+DROP TABLE IF EXISTS api_key_usage;
+DROP TABLE IF EXISTS api_keys;
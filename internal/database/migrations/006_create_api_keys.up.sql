@@ -0,0 +1,20 @@
+-- Create the 'api_keys' table; keys are only stored as their SHA-256 hash
+CREATE TABLE IF NOT EXISTS api_keys (
+                                        id SERIAL PRIMARY KEY,
+                                        name VARCHAR(255) NOT NULL,
+                                        prefix VARCHAR(16) NOT NULL,
+                                        key_hash CHAR(64) NOT NULL UNIQUE,
+                                        daily_quota INTEGER NOT NULL DEFAULT 10000,
+                                        rate_limit INTEGER NOT NULL DEFAULT 300,
+                                        created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+                                        last_used_at TIMESTAMP,
+                                        revoked_at TIMESTAMP
+);
+
+-- Create the 'api_key_usage' table counting the requests of each key per day
+CREATE TABLE IF NOT EXISTS api_key_usage (
+                                             api_key_id INTEGER NOT NULL REFERENCES api_keys(id) ON DELETE CASCADE,
+                                             date DATE NOT NULL,
+                                             requests INTEGER NOT NULL DEFAULT 0,
+                                             PRIMARY KEY (api_key_id, date)
+);
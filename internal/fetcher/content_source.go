@@ -0,0 +1,173 @@
+package fetcher
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"prosamik-backend/internal/cache"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DocumentRef identifies a single document inside a repository of a content source
+type DocumentRef struct {
+	Source       string // Name of the content source (e.g. "github")
+	Host         string // Host serving the repository
+	Owner        string // Repository owner, or the namespace path for GitLab
+	Repo         string // Repository name
+	Branch       string // Branch or ref; empty until ResolveBranch fills in the repository default
+	Path         string // File path within the repository
+	ExplicitPath bool   // Whether the URL pointed at a specific file or folder
+	Pinned       bool   // Branch is the commit a ?ref= resolved to and never changes
+	RefKind      string // "branch", "tag" or "commit" for hosts addressing refs by kind; empty is a branch
+}
+
+// RepositoryKey identifies the repository of the document across sources and hosts.
+// Owner and repository are lowercased since hosts treat them case-insensitively.
+func (r *DocumentRef) RepositoryKey() string {
+	return strings.ToLower(fmt.Sprintf("%s:%s/%s/%s", r.Source, r.Host, r.Owner, r.Repo))
+}
+
+// ContentSource fetches documents from one kind of content host
+type ContentSource interface {
+	// Name returns the identifier of the source
+	Name() string
+	// Matches reports whether the source can handle the given URL
+	Matches(u *url.URL) bool
+	// ParseURL turns a document URL into a DocumentRef
+	ParseURL(u *url.URL) (*DocumentRef, error)
+	// DefaultBranch looks up the default branch of the repository
+	DefaultBranch(ctx context.Context, ref *DocumentRef) (string, error)
+	// FetchContent returns the raw content of the document. Cached validators make
+	// the fetch conditional, and an unchanged document is reported as NotModified.
+	FetchContent(ctx context.Context, ref *DocumentRef, cached Validators) (*FetchResult, error)
+	// FetchLastUpdated returns the time of the last change to the document
+	FetchLastUpdated(ctx context.Context, ref *DocumentRef) (time.Time, error)
+	// RawFileURL returns a URL serving the raw bytes of a repository file,
+	// or an empty string when the file cannot be linked directly
+	RawFileURL(ref *DocumentRef, filePath string) string
+	// BlobURL returns the URL /md accepts for a repository file,
+	// which for hosted sources is the web page of the file
+	BlobURL(ref *DocumentRef, filePath string) string
+}
+
+var (
+	sources     []ContentSource
+	sourcesOnce sync.Once
+)
+
+// registeredSources returns the configured content sources.
+// Sources are built lazily so that environment variables loaded at startup are honoured.
+func registeredSources() []ContentSource {
+	sourcesOnce.Do(func() {
+		sources = []ContentSource{
+			&GitHubSource{},
+			&GitLabSource{hosts: hostList(os.Getenv("GITLAB_HOSTS"), "gitlab.com")},
+			&GiteaSource{hosts: hostList(os.Getenv("GITEA_HOSTS"), "")},
+		}
+		if root := strings.TrimSpace(os.Getenv("LOCAL_CONTENT_DIR")); root != "" {
+			sources = append(sources, &LocalSource{
+				root:    root,
+				baseURL: strings.TrimSpace(os.Getenv("LOCAL_CONTENT_BASE_URL")),
+			})
+		}
+	})
+	return sources
+}
+
+// SourceByName returns the registered content source with the given name
+func SourceByName(name string) (ContentSource, bool) {
+	for _, source := range registeredSources() {
+		if source.Name() == name {
+			return source, true
+		}
+	}
+	return nil, false
+}
+
+// ResolveSource selects the content source for a document URL and parses it
+func ResolveSource(rawURL string) (ContentSource, *DocumentRef, error) {
+	u, err := url.Parse(strings.TrimSpace(rawURL))
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid URL: %w", err)
+	}
+	// Sources build their API requests from the host alone, so credentials in the URL are refused
+	// rather than ignored; hosts with an explicit port match no source
+	if u.User != nil {
+		return nil, nil, fmt.Errorf("URLs with credentials are not supported: %s", u.Redacted())
+	}
+
+	for _, source := range registeredSources() {
+		if !source.Matches(u) {
+			continue
+		}
+		ref, err := source.ParseURL(u)
+		if err != nil {
+			return nil, nil, err
+		}
+		ref.Source = source.Name()
+		return source, ref, nil
+	}
+
+	return nil, nil, fmt.Errorf("unsupported content source: %s", rawURL)
+}
+
+// ResolveBranch fills in the repository's default branch when the URL did not name one.
+// Default branches are cached per repository so that only the first request pays for the lookup.
+func ResolveBranch(ctx context.Context, source ContentSource, ref *DocumentRef) error {
+	if ref.Branch != "" {
+		return nil
+	}
+
+	cacheKey := fmt.Sprintf("default_branch:%s:%s/%s/%s", source.Name(), ref.Host, ref.Owner, ref.Repo)
+	if cached, err := cache.GetCachedContent(ctx, cacheKey); err == nil && cached.Content != "" {
+		ref.Branch = cached.Content
+		return nil
+	}
+
+	branch, err := source.DefaultBranch(ctx, ref)
+	if err != nil {
+		return err
+	}
+	ref.Branch = branch
+
+	if branch != "" {
+		if err := cache.SetCachedContent(ctx, cacheKey, &cache.CachedContent{
+			Content:     branch,
+			LastUpdated: time.Now(),
+		}); err != nil {
+			fmt.Printf("Warning: failed to cache default branch: %v\n", err)
+		}
+	}
+
+	return nil
+}
+
+// hostList parses a comma separated list of host names
+func hostList(value, fallback string) map[string]bool {
+	if strings.TrimSpace(value) == "" {
+		value = fallback
+	}
+
+	hosts := make(map[string]bool)
+	for _, host := range strings.Split(value, ",") {
+		host = strings.ToLower(strings.TrimSpace(host))
+		if host != "" {
+			hosts[host] = true
+		}
+	}
+	return hosts
+}
+
+// splitPath splits a URL path into its non-empty segments
+func splitPath(p string) []string {
+	var segments []string
+	for _, segment := range strings.Split(p, "/") {
+		if segment != "" {
+			segments = append(segments, segment)
+		}
+	}
+	return segments
+}
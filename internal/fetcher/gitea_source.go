@@ -0,0 +1,191 @@
+package fetcher
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"path"
+	"prosamik-backend/internal/auth"
+	"strings"
+	"time"
+)
+
+// GiteaSource fetches documents from self-hosted Gitea (and Forgejo) instances
+type GiteaSource struct {
+	hosts map[string]bool
+}
+
+func (s *GiteaSource) Name() string {
+	return "gitea"
+}
+
+func (s *GiteaSource) Matches(u *url.URL) bool {
+	return u.Scheme == "https" && s.hosts[strings.ToLower(u.Host)]
+}
+
+// ParseURL handles repository URLs and /src/branch|tag|commit/ URLs.
+// Gitea uses /src/ for both files and folders, so paths without an extension are treated as folders.
+func (s *GiteaSource) ParseURL(u *url.URL) (*DocumentRef, error) {
+	parts := splitPath(u.Path)
+	if len(parts) < 2 {
+		return nil, fmt.Errorf("invalid Gitea URL format: %s", u.String())
+	}
+
+	ref := &DocumentRef{
+		Host:  u.Host,
+		Owner: parts[0],
+		Repo:  strings.TrimSuffix(parts[1], ".git"),
+		Path:  "README.md",
+	}
+
+	if len(parts) >= 5 && parts[2] == "src" &&
+		(parts[3] == "branch" || parts[3] == "tag" || parts[3] == "commit") {
+		ref.Branch = parts[4]
+		ref.RefKind = parts[3]
+		ref.ExplicitPath = true
+		ref.Path = strings.Join(parts[5:], "/")
+		if path.Ext(ref.Path) == "" {
+			ref.Path = strings.TrimPrefix(ref.Path+"/README.md", "/")
+		}
+	}
+
+	return ref, nil
+}
+
+// DefaultBranch reads default_branch from the repository API
+func (s *GiteaSource) DefaultBranch(ctx context.Context, ref *DocumentRef) (string, error) {
+	resp, err := makeSourceRequest(ctx, s.repoAPIURL(ref), "Gitea API", s.headers(), Validators{})
+	if err != nil {
+		return "", err
+	}
+
+	var repository GitHubRepository
+	if err := json.Unmarshal(resp.body, &repository); err != nil {
+		return "", fmt.Errorf("error unmarshalling Gitea repository response: %v", err)
+	}
+	if repository.DefaultBranch == "" {
+		return "", fmt.Errorf("repository %s/%s has no default branch", ref.Owner, ref.Repo)
+	}
+
+	return repository.DefaultBranch, nil
+}
+
+func (s *GiteaSource) FetchContent(ctx context.Context, ref *DocumentRef, cached Validators) (*FetchResult, error) {
+	apiURL := fmt.Sprintf("%s/raw/%s?ref=%s", s.repoAPIURL(ref), escapePath(ref.Path), url.QueryEscape(ref.Branch))
+
+	resp, err := makeSourceRequest(ctx, apiURL, "Gitea API", s.headers(), cached)
+	if err != nil {
+		return nil, err
+	}
+	if resp.notModified {
+		return &FetchResult{Validators: resp.validators, NotModified: true}, nil
+	}
+	if len(resp.body) == 0 {
+		return nil, fmt.Errorf("decoded content is empty")
+	}
+
+	return &FetchResult{Content: string(resp.body), Validators: resp.validators}, nil
+}
+
+// FetchLastUpdated reads the last commit touching the document; Gitea's
+// commit objects share the shape of GitHub's
+func (s *GiteaSource) FetchLastUpdated(ctx context.Context, ref *DocumentRef) (time.Time, error) {
+	apiURL := fmt.Sprintf("%s/commits?path=%s&sha=%s&limit=1",
+		s.repoAPIURL(ref), url.QueryEscape(ref.Path), url.QueryEscape(ref.Branch))
+
+	resp, err := makeSourceRequest(ctx, apiURL, "Gitea API", s.headers(), Validators{})
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	var commits []GitHubCommit
+	if err := json.Unmarshal(resp.body, &commits); err != nil {
+		return time.Time{}, fmt.Errorf("error unmarshalling Gitea commits response: %v", err)
+	}
+
+	if len(commits) == 0 {
+		return time.Time{}, fmt.Errorf("no commits found")
+	}
+
+	lastUpdated, err := time.Parse(time.RFC3339, commits[0].Commit.Committer.Date)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("error parsing commit date: %v", err)
+	}
+
+	return lastUpdated, nil
+}
+
+// FetchHistory lists the commits touching the document
+func (s *GiteaSource) FetchHistory(ctx context.Context, ref *DocumentRef, limit int) ([]Commit, error) {
+	apiURL := fmt.Sprintf("%s/commits?path=%s&sha=%s&limit=%d",
+		s.repoAPIURL(ref), url.QueryEscape(ref.Path), url.QueryEscape(ref.Branch), limit)
+
+	resp, err := makeSourceRequest(ctx, apiURL, "Gitea API", s.headers(), Validators{})
+	if err != nil {
+		return nil, err
+	}
+
+	var commits []GitHubCommit
+	if err := json.Unmarshal(resp.body, &commits); err != nil {
+		return nil, fmt.Errorf("error unmarshalling Gitea commits response: %v", err)
+	}
+
+	history := make([]Commit, 0, len(commits))
+	for i := range commits {
+		history = append(history, commits[i].toCommit())
+	}
+	return history, nil
+}
+
+func (s *GiteaSource) RawFileURL(ref *DocumentRef, filePath string) string {
+	return fmt.Sprintf("https://%s/%s/%s/raw/%s/%s/%s",
+		ref.Host, ref.Owner, ref.Repo, giteaRefKind(ref), ref.Branch, filePath)
+}
+
+func (s *GiteaSource) BlobURL(ref *DocumentRef, filePath string) string {
+	return fmt.Sprintf("https://%s/%s/%s/src/%s/%s/%s",
+		ref.Host, ref.Owner, ref.Repo, giteaRefKind(ref), ref.Branch, filePath)
+}
+
+// giteaRefKind returns the path segment Gitea addresses the document's ref with
+func giteaRefKind(ref *DocumentRef) string {
+	if ref.RefKind == "" {
+		return "branch"
+	}
+	return ref.RefKind
+}
+
+func (s *GiteaSource) repoAPIURL(ref *DocumentRef) string {
+	return fmt.Sprintf("https://%s/api/v1/repos/%s/%s", ref.Host, ref.Owner, ref.Repo)
+}
+
+func (s *GiteaSource) headers() map[string]string {
+	token := auth.GetGiteaToken()
+	if token == "" {
+		return nil
+	}
+	return map[string]string{"Authorization": "token " + token}
+}
+
+// escapePath escapes every segment of a repository file path
+func escapePath(filePath string) string {
+	segments := strings.Split(filePath, "/")
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(segment)
+	}
+	return strings.Join(segments, "/")
+}
+
+// IssueURL links #123 references; Gitea redirects issue URLs to pull requests when needed
+func (s *GiteaSource) IssueURL(host, owner, repo, number string) string {
+	return fmt.Sprintf("https://%s/%s/%s/issues/%s", host, owner, repo, number)
+}
+
+func (s *GiteaSource) UserURL(host, user string) string {
+	return fmt.Sprintf("https://%s/%s", host, user)
+}
+
+func (s *GiteaSource) CommitURL(host, owner, repo, sha string) string {
+	return fmt.Sprintf("https://%s/%s/%s/commit/%s", host, owner, repo, sha)
+}
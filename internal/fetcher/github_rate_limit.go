@@ -0,0 +1,135 @@
+package fetcher
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// gitHubAPIName names the GitHub REST API in errors and selects its rate-limit tracking
+const gitHubAPIName = "GitHub API"
+
+// defaultGitHubRateLimitReserve is the number of requests kept in reserve when
+// GITHUB_RATE_LIMIT_RESERVE is not set; requests fail fast once only the reserve is left
+const defaultGitHubRateLimitReserve = 10
+
+// RateLimitStatus is the last GitHub API rate-limit state seen on a response
+type RateLimitStatus struct {
+	Known     bool      `json:"known"` // Whether any GitHub API response has been seen yet
+	Limit     int       `json:"limit"`
+	Remaining int       `json:"remaining"`
+	Used      int       `json:"used"`
+	Reset     time.Time `json:"reset"`      // When the quota resets
+	Reserve   int       `json:"reserve"`    // Requests kept in reserve before failing fast
+	UpdatedAt time.Time `json:"updated_at"` // When the state was last updated
+}
+
+// RateLimitError is returned when the GitHub API quota is exhausted or about to be
+type RateLimitError struct {
+	RetryAt time.Time // When requests are expected to succeed again
+}
+
+func (e *RateLimitError) Error() string {
+	return fmt.Sprintf("GitHub API rate limit exceeded, retry at %s", e.RetryAt.UTC().Format(time.RFC3339))
+}
+
+// RetryAfter returns how long until requests are expected to succeed again, at least one second
+func (e *RateLimitError) RetryAfter() time.Duration {
+	if wait := time.Until(e.RetryAt).Round(time.Second); wait > time.Second {
+		return wait
+	}
+	return time.Second
+}
+
+// gitHubRateLimiter tracks the core rate limit of the GitHub API from response headers
+type gitHubRateLimiter struct {
+	mu           sync.Mutex
+	status       RateLimitStatus
+	blockedUntil time.Time // Set by secondary rate limits that answer with Retry-After
+}
+
+var gitHubRateLimit = &gitHubRateLimiter{}
+
+// GitHubRateLimit returns the last known GitHub API rate-limit state
+func GitHubRateLimit() RateLimitStatus {
+	gitHubRateLimit.mu.Lock()
+	defer gitHubRateLimit.mu.Unlock()
+
+	status := gitHubRateLimit.status
+	status.Reserve = gitHubRateLimitReserve()
+	return status
+}
+
+// CheckGitHubRateLimit returns a RateLimitError while GitHub API requests would fail fast,
+// letting background work pause before it starts
+func CheckGitHubRateLimit() error {
+	return gitHubRateLimit.check()
+}
+
+// gitHubRateLimitReserve reads GITHUB_RATE_LIMIT_RESERVE
+func gitHubRateLimitReserve() int {
+	if value := os.Getenv("GITHUB_RATE_LIMIT_RESERVE"); value != "" {
+		if reserve, err := strconv.Atoi(value); err == nil && reserve >= 0 {
+			return reserve
+		}
+		fmt.Printf("Warning: invalid GITHUB_RATE_LIMIT_RESERVE %q, using %d\n", value, defaultGitHubRateLimitReserve)
+	}
+	return defaultGitHubRateLimitReserve
+}
+
+// check fails fast while the quota is down to the reserve or a secondary limit is in effect
+func (l *gitHubRateLimiter) check() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	if now.Before(l.blockedUntil) {
+		return &RateLimitError{RetryAt: l.blockedUntil}
+	}
+	if l.status.Known && now.Before(l.status.Reset) && l.status.Remaining <= gitHubRateLimitReserve() {
+		return &RateLimitError{RetryAt: l.status.Reset}
+	}
+	return nil
+}
+
+// observe records the rate-limit headers of a response and reports rate-limited responses
+func (l *gitHubRateLimiter) observe(resp *http.Response) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	// Only the core quota is tracked; search and GraphQL have their own
+	resource := resp.Header.Get("X-RateLimit-Resource")
+	limit, limitErr := strconv.Atoi(resp.Header.Get("X-RateLimit-Limit"))
+	remaining, remainingErr := strconv.Atoi(resp.Header.Get("X-RateLimit-Remaining"))
+	reset, resetErr := strconv.ParseInt(resp.Header.Get("X-RateLimit-Reset"), 10, 64)
+	if (resource == "" || resource == "core") && limitErr == nil && remainingErr == nil && resetErr == nil {
+		used, _ := strconv.Atoi(resp.Header.Get("X-RateLimit-Used"))
+		l.status = RateLimitStatus{
+			Known:     true,
+			Limit:     limit,
+			Remaining: remaining,
+			Used:      used,
+			Reset:     time.Unix(reset, 0),
+			UpdatedAt: time.Now(),
+		}
+	}
+
+	if resp.StatusCode != http.StatusForbidden && resp.StatusCode != http.StatusTooManyRequests {
+		return nil
+	}
+
+	// Secondary rate limits say how long to wait; primary ones run until the reset
+	if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil {
+		l.blockedUntil = time.Now().Add(time.Duration(seconds) * time.Second)
+		return &RateLimitError{RetryAt: l.blockedUntil}
+	}
+	if remainingErr == nil && remaining == 0 && resetErr == nil {
+		return &RateLimitError{RetryAt: time.Unix(reset, 0)}
+	}
+
+	// Any other 403 is a permission error
+	return nil
+}
@@ -0,0 +1,160 @@
+package fetcher
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// GitHubSource fetches documents through the GitHub REST API
+type GitHubSource struct{}
+
+func (s *GitHubSource) Name() string {
+	return "github"
+}
+
+func (s *GitHubSource) Matches(u *url.URL) bool {
+	host := strings.ToLower(u.Host)
+	return u.Scheme == "https" && (host == "github.com" || host == "www.github.com")
+}
+
+// ParseURL handles repository, /blob/ and /tree/ URLs
+func (s *GitHubSource) ParseURL(u *url.URL) (*DocumentRef, error) {
+	parts := splitPath(u.Path)
+	if len(parts) < 2 {
+		return nil, fmt.Errorf("invalid GitHub URL format: %s", u.String())
+	}
+
+	ref := &DocumentRef{
+		Host:  "github.com",
+		Owner: parts[0],
+		Repo:  strings.TrimSuffix(parts[1], ".git"),
+		Path:  "README.md",
+	}
+
+	if len(parts) >= 4 && (parts[2] == "blob" || parts[2] == "tree") {
+		ref.Branch = parts[3]
+		ref.ExplicitPath = true
+		ref.Path = strings.Join(parts[4:], "/")
+		if parts[2] == "tree" {
+			ref.Path = strings.TrimPrefix(ref.Path+"/README.md", "/")
+		}
+	}
+
+	return ref, nil
+}
+
+// DefaultBranch reads default_branch from the repository API
+func (s *GitHubSource) DefaultBranch(ctx context.Context, ref *DocumentRef) (string, error) {
+	body, err := makeGitHubRequest(ctx, fmt.Sprintf("https://api.github.com/repos/%s/%s", ref.Owner, ref.Repo))
+	if err != nil {
+		return "", err
+	}
+
+	var repository GitHubRepository
+	if err := json.Unmarshal(body, &repository); err != nil {
+		return "", fmt.Errorf("error unmarshalling GitHub repository response: %v", err)
+	}
+	if repository.DefaultBranch == "" {
+		return "", fmt.Errorf("repository %s/%s has no default branch", ref.Owner, ref.Repo)
+	}
+
+	return repository.DefaultBranch, nil
+}
+
+func (s *GitHubSource) FetchContent(ctx context.Context, ref *DocumentRef, cached Validators) (*FetchResult, error) {
+	return FetchContentFromGitHubURL(ctx, s.contentsURL(ref), cached)
+}
+
+// FetchLastUpdated reads the last commit touching the document
+func (s *GitHubSource) FetchLastUpdated(ctx context.Context, ref *DocumentRef) (time.Time, error) {
+	return FetchLastCommitData(ctx, s.commitsURL(ref, 1))
+}
+
+// FetchHistory lists the commits touching the document
+func (s *GitHubSource) FetchHistory(ctx context.Context, ref *DocumentRef, limit int) ([]Commit, error) {
+	body, err := makeGitHubRequest(ctx, s.commitsURL(ref, limit))
+	if err != nil {
+		return nil, err
+	}
+
+	var commits []GitHubCommit
+	if err := json.Unmarshal(body, &commits); err != nil {
+		return nil, fmt.Errorf("error unmarshalling GitHub commits response: %v", err)
+	}
+
+	history := make([]Commit, 0, len(commits))
+	for i := range commits {
+		history = append(history, commits[i].toCommit())
+	}
+	return history, nil
+}
+
+// gitHubTree is a recursive listing of the git trees API
+type gitHubTree struct {
+	Tree []struct {
+		Path string `json:"path"`
+		Type string `json:"type"`
+		SHA  string `json:"sha"`
+	} `json:"tree"`
+	Truncated bool `json:"truncated"`
+}
+
+// FetchTree lists the files below dir with a single recursive trees API request
+func (s *GitHubSource) FetchTree(ctx context.Context, ref *DocumentRef, dir string) ([]TreeEntry, bool, error) {
+	body, err := makeGitHubRequest(ctx, fmt.Sprintf("https://api.github.com/repos/%s/%s/git/trees/%s?recursive=1",
+		ref.Owner, ref.Repo, ref.Branch))
+	if err != nil {
+		return nil, false, err
+	}
+
+	var tree gitHubTree
+	if err := json.Unmarshal(body, &tree); err != nil {
+		return nil, false, fmt.Errorf("error unmarshalling GitHub tree response: %v", err)
+	}
+
+	var entries []TreeEntry
+	for _, item := range tree.Tree {
+		if item.Type == "blob" && inDir(item.Path, dir) {
+			entries = append(entries, TreeEntry{Path: item.Path, SHA: item.SHA})
+		}
+	}
+	return entries, tree.Truncated, nil
+}
+
+func (s *GitHubSource) RawFileURL(ref *DocumentRef, filePath string) string {
+	return fmt.Sprintf("https://raw.githubusercontent.com/%s/%s/%s/%s",
+		ref.Owner, ref.Repo, ref.Branch, filePath)
+}
+
+func (s *GitHubSource) BlobURL(ref *DocumentRef, filePath string) string {
+	return fmt.Sprintf("https://github.com/%s/%s/blob/%s/%s", ref.Owner, ref.Repo, ref.Branch, filePath)
+}
+
+// contentsURL builds the contents API URL for the document
+func (s *GitHubSource) contentsURL(ref *DocumentRef) string {
+	return fmt.Sprintf("https://api.github.com/repos/%s/%s/contents/%s?ref=%s",
+		ref.Owner, ref.Repo, ref.Path, url.QueryEscape(ref.Branch))
+}
+
+// commitsURL builds the commits API URL returning the last commits of the document
+func (s *GitHubSource) commitsURL(ref *DocumentRef, perPage int) string {
+	return fmt.Sprintf("https://api.github.com/repos/%s/%s/commits?path=%s&sha=%s&page=1&per_page=%d",
+		ref.Owner, ref.Repo, url.QueryEscape(ref.Path), url.QueryEscape(ref.Branch), perPage)
+}
+
+// IssueURL links #123 references; GitHub redirects issue URLs to pull requests when needed
+func (s *GitHubSource) IssueURL(_, owner, repo, number string) string {
+	return fmt.Sprintf("https://github.com/%s/%s/issues/%s", owner, repo, number)
+}
+
+func (s *GitHubSource) UserURL(_, user string) string {
+	return "https://github.com/" + user
+}
+
+func (s *GitHubSource) CommitURL(_, owner, repo, sha string) string {
+	return fmt.Sprintf("https://github.com/%s/%s/commit/%s", owner, repo, sha)
+}
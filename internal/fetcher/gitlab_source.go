@@ -0,0 +1,200 @@
+package fetcher
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"prosamik-backend/internal/auth"
+	"strings"
+	"time"
+)
+
+// GitLabSource fetches documents from gitlab.com or self-hosted GitLab instances
+type GitLabSource struct {
+	hosts map[string]bool
+}
+
+// gitLabCommit represents a single commit in GitLab's commits API response
+type gitLabCommit struct {
+	ID            string `json:"id"`
+	Message       string `json:"message"`
+	AuthorName    string `json:"author_name"`
+	AuthoredDate  string `json:"authored_date"`
+	CommittedDate string `json:"committed_date"`
+	WebURL        string `json:"web_url"`
+}
+
+func (s *GitLabSource) Name() string {
+	return "gitlab"
+}
+
+func (s *GitLabSource) Matches(u *url.URL) bool {
+	return u.Scheme == "https" && s.hosts[strings.ToLower(u.Host)]
+}
+
+// ParseURL handles project URLs and /-/blob/ or /-/tree/ URLs.
+// Projects may live in nested groups, so everything before the "-" separator is the project path.
+func (s *GitLabSource) ParseURL(u *url.URL) (*DocumentRef, error) {
+	parts := splitPath(u.Path)
+
+	projectParts := parts
+	var rest []string
+	for i, part := range parts {
+		if part == "-" {
+			projectParts = parts[:i]
+			rest = parts[i+1:]
+			break
+		}
+	}
+
+	if len(projectParts) < 2 {
+		return nil, fmt.Errorf("invalid GitLab URL format: %s", u.String())
+	}
+
+	ref := &DocumentRef{
+		Host:  u.Host,
+		Owner: strings.Join(projectParts[:len(projectParts)-1], "/"),
+		Repo:  strings.TrimSuffix(projectParts[len(projectParts)-1], ".git"),
+		Path:  "README.md",
+	}
+
+	if len(rest) >= 2 && (rest[0] == "blob" || rest[0] == "tree") {
+		ref.Branch = rest[1]
+		ref.ExplicitPath = true
+		ref.Path = strings.Join(rest[2:], "/")
+		if rest[0] == "tree" {
+			ref.Path = strings.TrimPrefix(ref.Path+"/README.md", "/")
+		}
+	}
+
+	return ref, nil
+}
+
+// DefaultBranch reads default_branch from the project API
+func (s *GitLabSource) DefaultBranch(ctx context.Context, ref *DocumentRef) (string, error) {
+	resp, err := makeSourceRequest(ctx, s.projectAPIURL(ref), "GitLab API", s.headers(), Validators{})
+	if err != nil {
+		return "", err
+	}
+
+	var project struct {
+		DefaultBranch string `json:"default_branch"`
+	}
+	if err := json.Unmarshal(resp.body, &project); err != nil {
+		return "", fmt.Errorf("error unmarshalling GitLab project response: %v", err)
+	}
+	if project.DefaultBranch == "" {
+		return "", fmt.Errorf("project %s/%s has no default branch", ref.Owner, ref.Repo)
+	}
+
+	return project.DefaultBranch, nil
+}
+
+func (s *GitLabSource) FetchContent(ctx context.Context, ref *DocumentRef, cached Validators) (*FetchResult, error) {
+	apiURL := fmt.Sprintf("%s/repository/files/%s/raw?ref=%s",
+		s.projectAPIURL(ref), url.PathEscape(ref.Path), url.QueryEscape(ref.Branch))
+
+	resp, err := makeSourceRequest(ctx, apiURL, "GitLab API", s.headers(), cached)
+	if err != nil {
+		return nil, err
+	}
+	if resp.notModified {
+		return &FetchResult{Validators: resp.validators, NotModified: true}, nil
+	}
+	if len(resp.body) == 0 {
+		return nil, fmt.Errorf("decoded content is empty")
+	}
+
+	return &FetchResult{Content: string(resp.body), Validators: resp.validators}, nil
+}
+
+func (s *GitLabSource) FetchLastUpdated(ctx context.Context, ref *DocumentRef) (time.Time, error) {
+	apiURL := fmt.Sprintf("%s/repository/commits?path=%s&ref_name=%s&per_page=1",
+		s.projectAPIURL(ref), url.QueryEscape(ref.Path), url.QueryEscape(ref.Branch))
+
+	resp, err := makeSourceRequest(ctx, apiURL, "GitLab API", s.headers(), Validators{})
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	var commits []gitLabCommit
+	if err := json.Unmarshal(resp.body, &commits); err != nil {
+		return time.Time{}, fmt.Errorf("error unmarshalling GitLab commits response: %v", err)
+	}
+
+	if len(commits) == 0 {
+		return time.Time{}, fmt.Errorf("no commits found")
+	}
+
+	lastUpdated, err := time.Parse(time.RFC3339, commits[0].CommittedDate)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("error parsing commit date: %v", err)
+	}
+
+	return lastUpdated, nil
+}
+
+// FetchHistory lists the commits touching the document. GitLab commits carry
+// no account of their author, so the history has names but no logins or avatars.
+func (s *GitLabSource) FetchHistory(ctx context.Context, ref *DocumentRef, limit int) ([]Commit, error) {
+	apiURL := fmt.Sprintf("%s/repository/commits?path=%s&ref_name=%s&per_page=%d",
+		s.projectAPIURL(ref), url.QueryEscape(ref.Path), url.QueryEscape(ref.Branch), limit)
+
+	resp, err := makeSourceRequest(ctx, apiURL, "GitLab API", s.headers(), Validators{})
+	if err != nil {
+		return nil, err
+	}
+
+	var commits []gitLabCommit
+	if err := json.Unmarshal(resp.body, &commits); err != nil {
+		return nil, fmt.Errorf("error unmarshalling GitLab commits response: %v", err)
+	}
+
+	history := make([]Commit, 0, len(commits))
+	for _, c := range commits {
+		commit := Commit{
+			SHA:        c.ID,
+			Message:    strings.TrimSpace(c.Message),
+			AuthorName: c.AuthorName,
+			URL:        c.WebURL,
+		}
+		if date, err := time.Parse(time.RFC3339, c.AuthoredDate); err == nil {
+			commit.Date = date
+		}
+		history = append(history, commit)
+	}
+	return history, nil
+}
+
+func (s *GitLabSource) RawFileURL(ref *DocumentRef, filePath string) string {
+	return fmt.Sprintf("https://%s/%s/%s/-/raw/%s/%s",
+		ref.Host, ref.Owner, ref.Repo, ref.Branch, filePath)
+}
+
+func (s *GitLabSource) BlobURL(ref *DocumentRef, filePath string) string {
+	return fmt.Sprintf("https://%s/%s/%s/-/blob/%s/%s",
+		ref.Host, ref.Owner, ref.Repo, ref.Branch, filePath)
+}
+
+// projectAPIURL returns the API base URL of the project, addressed by its URL-encoded path
+func (s *GitLabSource) projectAPIURL(ref *DocumentRef) string {
+	return fmt.Sprintf("https://%s/api/v4/projects/%s",
+		ref.Host, url.PathEscape(ref.Owner+"/"+ref.Repo))
+}
+
+func (s *GitLabSource) headers() map[string]string {
+	return map[string]string{"PRIVATE-TOKEN": auth.GetGitLabToken()}
+}
+
+func (s *GitLabSource) IssueURL(host, owner, repo, number string) string {
+	return fmt.Sprintf("https://%s/%s/%s/-/issues/%s", host, owner, repo, number)
+}
+
+func (s *GitLabSource) UserURL(host, user string) string {
+	return fmt.Sprintf("https://%s/%s", host, user)
+}
+
+func (s *GitLabSource) CommitURL(host, owner, repo, sha string) string {
+	return fmt.Sprintf("https://%s/%s/%s/-/commit/%s", host, owner, repo, sha)
+}
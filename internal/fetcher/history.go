@@ -0,0 +1,61 @@
+package fetcher
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+)
+
+// MaxHistory caps the number of commits requested from a source
+const MaxHistory = 100
+
+// Commit is a commit touching a document
+type Commit struct {
+	SHA         string
+	Message     string
+	AuthorName  string
+	AuthorLogin string // Account of the author on the source; empty when unknown
+	AvatarURL   string
+	Date        time.Time // Author date
+	URL         string    // Web page of the commit
+}
+
+// HistorySource is implemented by sources that can list the commits touching a document
+type HistorySource interface {
+	// FetchHistory returns up to limit commits touching the document, newest first
+	FetchHistory(ctx context.Context, ref *DocumentRef, limit int) ([]Commit, error)
+}
+
+// ErrHistoryUnsupported is returned for sources without commit history
+var ErrHistoryUnsupported = errors.New("content source has no commit history")
+
+// FetchHistory returns up to limit commits touching the document, newest first
+func FetchHistory(ctx context.Context, source ContentSource, ref *DocumentRef, limit int) ([]Commit, error) {
+	hs, ok := source.(HistorySource)
+	if !ok {
+		return nil, ErrHistoryUnsupported
+	}
+	if limit > MaxHistory {
+		limit = MaxHistory
+	}
+	return hs.FetchHistory(ctx, ref, limit)
+}
+
+// toCommit converts a GitHub or Gitea commit
+func (c *GitHubCommit) toCommit() Commit {
+	commit := Commit{
+		SHA:        c.SHA,
+		Message:    strings.TrimSpace(c.Commit.Message),
+		AuthorName: c.Commit.Author.Name,
+		URL:        c.HTMLURL,
+	}
+	if c.Author != nil {
+		commit.AuthorLogin = c.Author.Login
+		commit.AvatarURL = c.Author.AvatarURL
+	}
+	if date, err := time.Parse(time.RFC3339, c.Commit.Author.Date); err == nil {
+		commit.Date = date
+	}
+	return commit
+}
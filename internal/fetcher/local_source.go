@@ -0,0 +1,171 @@
+package fetcher
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// LocalSource serves documents from a directory on the local filesystem.
+// Documents are addressed as local:///path/to/file.md relative to the configured root.
+type LocalSource struct {
+	root    string
+	baseURL string // Optional public URL the root directory is served from
+}
+
+func (s *LocalSource) Name() string {
+	return "local"
+}
+
+func (s *LocalSource) Matches(u *url.URL) bool {
+	return u.Scheme == "local"
+}
+
+func (s *LocalSource) ParseURL(u *url.URL) (*DocumentRef, error) {
+	p := u.Path
+	if p == "" {
+		p = u.Opaque
+	}
+
+	// Clean against the root so the path can never escape the content directory
+	cleaned := strings.TrimPrefix(path.Clean("/"+p), "/")
+
+	ref := &DocumentRef{
+		Host:         "local",
+		Owner:        "local",
+		Repo:         filepath.Base(s.root),
+		Path:         cleaned,
+		ExplicitPath: cleaned != "",
+	}
+
+	resolved, err := s.resolvePath(cleaned)
+	if err != nil {
+		return nil, fmt.Errorf("local document not found: %s", u.String())
+	}
+	info, err := os.Stat(resolved)
+	if err != nil {
+		return nil, fmt.Errorf("local document not found: %s", u.String())
+	}
+	if info.IsDir() {
+		ref.Path = strings.TrimPrefix(cleaned+"/README.md", "/")
+	}
+
+	return ref, nil
+}
+
+// DefaultBranch returns an empty branch since local directories are not versioned
+func (s *LocalSource) DefaultBranch(_ context.Context, _ *DocumentRef) (string, error) {
+	return "", nil
+}
+
+// FetchContent uses the modification time of the file as its validator
+func (s *LocalSource) FetchContent(_ context.Context, ref *DocumentRef, cached Validators) (*FetchResult, error) {
+	resolved, err := s.resolvePath(ref.Path)
+	if err != nil {
+		return nil, fmt.Errorf("reading local document: %w", err)
+	}
+	info, err := os.Stat(resolved)
+	if err != nil {
+		return nil, fmt.Errorf("reading local document info: %w", err)
+	}
+
+	validators := Validators{LastModified: info.ModTime().UTC().Format(http.TimeFormat)}
+	if cached.LastModified != "" && cached.LastModified == validators.LastModified {
+		return &FetchResult{Validators: validators, NotModified: true}, nil
+	}
+
+	content, err := os.ReadFile(resolved)
+	if err != nil {
+		return nil, fmt.Errorf("reading local document: %w", err)
+	}
+	if len(content) == 0 {
+		return nil, fmt.Errorf("decoded content is empty")
+	}
+	return &FetchResult{Content: string(content), Validators: validators}, nil
+}
+
+// FetchLastUpdated uses the modification time of the file
+func (s *LocalSource) FetchLastUpdated(_ context.Context, ref *DocumentRef) (time.Time, error) {
+	resolved, err := s.resolvePath(ref.Path)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("reading local document info: %w", err)
+	}
+	info, err := os.Stat(resolved)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("reading local document info: %w", err)
+	}
+	return info.ModTime().UTC(), nil
+}
+
+// FetchTree walks the directory, skipping hidden files and folders
+func (s *LocalSource) FetchTree(_ context.Context, _ *DocumentRef, dir string) ([]TreeEntry, bool, error) {
+	var entries []TreeEntry
+	err := filepath.WalkDir(s.filePath(dir), func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if strings.HasPrefix(d.Name(), ".") && p != s.filePath(dir) {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.Type().IsRegular() {
+			rel, err := filepath.Rel(s.root, p)
+			if err != nil {
+				return err
+			}
+			entries = append(entries, TreeEntry{Path: filepath.ToSlash(rel)})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, false, fmt.Errorf("listing local directory: %w", err)
+	}
+	return entries, false, nil
+}
+
+// RawFileURL links files through LOCAL_CONTENT_BASE_URL when it is configured
+func (s *LocalSource) RawFileURL(_ *DocumentRef, filePath string) string {
+	if s.baseURL == "" {
+		return ""
+	}
+	return strings.TrimSuffix(s.baseURL, "/") + "/" + filePath
+}
+
+// BlobURL addresses the file with the local:// scheme /md accepts
+func (s *LocalSource) BlobURL(_ *DocumentRef, filePath string) string {
+	return "local:///" + filePath
+}
+
+// filePath maps a cleaned document path onto the content directory
+func (s *LocalSource) filePath(p string) string {
+	return filepath.Join(s.root, filepath.FromSlash(p))
+}
+
+// resolvePath maps a cleaned document path onto the content directory and follows its symlinks.
+// Cleaning keeps ".." out of the path, but a symlink inside the directory can still point
+// anywhere on the host, so paths resolving outside the directory are refused as not existing.
+func (s *LocalSource) resolvePath(p string) (string, error) {
+	root, err := filepath.EvalSymlinks(s.root)
+	if err != nil {
+		return "", fmt.Errorf("resolving content directory: %w", err)
+	}
+	resolved, err := filepath.EvalSymlinks(s.filePath(p))
+	if err != nil {
+		return "", err
+	}
+
+	rel, err := filepath.Rel(root, resolved)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("%s resolves outside the content directory: %w", p, fs.ErrNotExist)
+	}
+	return resolved, nil
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -12,6 +13,9 @@ import (
 	"time"
 )
 
+// ErrNotFound is returned when a content source answers that a document or repository does not exist
+var ErrNotFound = errors.New("not found")
+
 // GitHubFile represents the structure for file content response
 type GitHubFile struct {
 	Name    string `json:"name"`
@@ -19,44 +23,91 @@ type GitHubFile struct {
 	Content string `json:"content"`
 }
 
+// GitHubRepository represents the fields of the repository API response used by the fetcher
+type GitHubRepository struct {
+	DefaultBranch string `json:"default_branch"`
+	Private       bool   `json:"private"`
+}
+
 // GitHubCommit represents a single commit in the commit API response
 type GitHubCommit struct {
-	Commit struct {
-		Committer struct {
-			Date string `json:"date"`
-		} `json:"committer"`
+	SHA     string `json:"sha"`
+	HTMLURL string `json:"html_url"`
+	Commit  struct {
+		Author    GitHubCommitSignature `json:"author"`
+		Committer GitHubCommitSignature `json:"committer"`
+		Message   string                `json:"message"`
 	} `json:"commit"`
+	Author *GitHubUser `json:"author"` // Account of the commit author; nil when the email matches none
+}
+
+// GitHubCommitSignature is the git author or committer of a commit
+type GitHubCommitSignature struct {
+	Name  string `json:"name"`
+	Email string `json:"email"`
+	Date  string `json:"date"`
+}
+
+// GitHubUser represents the fields of a user account used by the fetcher
+type GitHubUser struct {
+	Login     string `json:"login"`
+	AvatarURL string `json:"avatar_url"`
+}
+
+// Validators hold the HTTP cache validators returned with a document
+type Validators struct {
+	ETag         string
+	LastModified string
+}
+
+// FetchResult is the outcome of a conditional content fetch
+type FetchResult struct {
+	Content     string     // Raw document content; empty when NotModified is set
+	Validators  Validators // Validators of the current upstream version
+	NotModified bool       // Upstream reported that the cached version is still current
+}
+
+// sourceResponse holds the parts of an upstream HTTP response the fetchers use
+type sourceResponse struct {
+	body        []byte
+	validators  Validators
+	notModified bool
 }
 
-// FetchContentFromGitHubURL fetches file content from GitHub API
-func FetchContentFromGitHubURL(ctx context.Context, apiURL string) (string, error) {
+// FetchContentFromGitHubURL fetches file content from GitHub API.
+// Cached validators turn the request into a conditional one, which GitHub
+// answers with 304 Not Modified without counting it against the rate limit.
+func FetchContentFromGitHubURL(ctx context.Context, apiURL string, cached Validators) (*FetchResult, error) {
 	// Fetch content from GitHub
-	content, err := fetchFreshContent(ctx, apiURL)
+	result, err := fetchFreshContent(ctx, apiURL, cached)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
-	return content, nil
+	return result, nil
 }
 
 // fetchFreshContent contains the content fetching logic
-func fetchFreshContent(ctx context.Context, apiURL string) (string, error) {
-	body, err := makeGitHubRequest(ctx, apiURL)
+func fetchFreshContent(ctx context.Context, apiURL string, cached Validators) (*FetchResult, error) {
+	resp, err := makeConditionalGitHubRequest(ctx, apiURL, cached)
 	if err != nil {
-		return "", err
+		return nil, err
+	}
+	if resp.notModified {
+		return &FetchResult{Validators: resp.validators, NotModified: true}, nil
 	}
 
 	var fileContent GitHubFile
-	if err := json.Unmarshal(body, &fileContent); err != nil {
-		return "", fmt.Errorf("error unmarshalling GitHub file response: %v", err)
+	if err := json.Unmarshal(resp.body, &fileContent); err != nil {
+		return nil, fmt.Errorf("error unmarshalling GitHub file response: %v", err)
 	}
 
 	decodedContent, err := decodeBase64Content(fileContent.Content)
 	if err != nil {
-		return "", fmt.Errorf("error decoding base64 content: %v", err)
+		return nil, fmt.Errorf("error decoding base64 content: %v", err)
 	}
 
-	return decodedContent, nil
+	return &FetchResult{Content: decodedContent, Validators: resp.validators}, nil
 }
 
 // FetchLastCommitData fetches the last commit information for a file or repository
@@ -86,11 +137,55 @@ func FetchLastCommitData(ctx context.Context, apiURL string) (time.Time, error)
 
 // makeGitHubRequest makes a generic HTTP request to GitHub API
 func makeGitHubRequest(ctx context.Context, url string) ([]byte, error) {
+	resp, err := makeConditionalGitHubRequest(ctx, url, Validators{})
+	if err != nil {
+		return nil, err
+	}
+	return resp.body, nil
+}
+
+// makeConditionalGitHubRequest makes a GitHub API request carrying the given cache validators.
+// Requests fail fast with a RateLimitError while the rate limit is (nearly) exhausted.
+func makeConditionalGitHubRequest(ctx context.Context, url string, cached Validators) (*sourceResponse, error) {
+	if err := gitHubRateLimit.check(); err != nil {
+		return nil, err
+	}
+
 	req, err := createRequest(ctx, url)
 	if err != nil {
 		return nil, fmt.Errorf("creating request: %w", err)
 	}
 
+	return doRequest(req, gitHubAPIName, cached)
+}
+
+// makeSourceRequest makes an HTTP GET request to a non-GitHub content source,
+// adding the given headers when their values are not empty
+func makeSourceRequest(ctx context.Context, url, sourceName string, headers map[string]string, cached Validators) (*sourceResponse, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("creating request: %w", err)
+	}
+
+	for name, value := range headers {
+		if value != "" {
+			req.Header.Set(name, value)
+		}
+	}
+
+	return doRequest(req, sourceName, cached)
+}
+
+// doRequest executes the request and returns the body of a successful response.
+// A 304 response to a conditional request is reported through notModified.
+func doRequest(req *http.Request, sourceName string, cached Validators) (*sourceResponse, error) {
+	if cached.ETag != "" {
+		req.Header.Set("If-None-Match", cached.ETag)
+	}
+	if cached.LastModified != "" {
+		req.Header.Set("If-Modified-Since", cached.LastModified)
+	}
+
 	client := &http.Client{Timeout: 10 * time.Second}
 	resp, err := client.Do(req)
 	if err != nil {
@@ -102,8 +197,33 @@ func makeGitHubRequest(ctx context.Context, url string) ([]byte, error) {
 		}
 	}()
 
+	if sourceName == gitHubAPIName {
+		if err := gitHubRateLimit.observe(resp); err != nil {
+			return nil, err
+		}
+	}
+
+	validators := Validators{
+		ETag:         resp.Header.Get("ETag"),
+		LastModified: resp.Header.Get("Last-Modified"),
+	}
+
+	if resp.StatusCode == http.StatusNotModified {
+		// A 304 may omit validators, in which case the cached ones stay valid
+		if validators.ETag == "" {
+			validators.ETag = cached.ETag
+		}
+		if validators.LastModified == "" {
+			validators.LastModified = cached.LastModified
+		}
+		return &sourceResponse{validators: validators, notModified: true}, nil
+	}
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, fmt.Errorf("%s returned non-OK status: %s: %w", sourceName, resp.Status, ErrNotFound)
+	}
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("GitHub API returned non-OK status: %s", resp.Status)
+		return nil, fmt.Errorf("%s returned non-OK status: %s", sourceName, resp.Status)
 	}
 
 	body, err := io.ReadAll(resp.Body)
@@ -111,7 +231,7 @@ func makeGitHubRequest(ctx context.Context, url string) ([]byte, error) {
 		return nil, fmt.Errorf("reading response body: %w", err)
 	}
 
-	return body, nil
+	return &sourceResponse{body: body, validators: validators}, nil
 }
 
 func createRequest(ctx context.Context, url string) (*http.Request, error) {
@@ -0,0 +1,137 @@
+package fetcher
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"prosamik-backend/internal/cache"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// pinnedRefPattern matches the tag, branch and commit names accepted as refs
+var pinnedRefPattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._/-]{0,199}$`)
+
+// commitSHAPattern matches full SHA-1 and SHA-256 commit ids
+var commitSHAPattern = regexp.MustCompile(`^([0-9a-f]{40}|[0-9a-f]{64})$`)
+
+// commitResolutionTTL is how long the commit a ref name points at is cached; branches move, so it is short
+const commitResolutionTTL = 5 * time.Minute
+
+// CommitSource is implemented by sources that can resolve a tag, branch or commit to its commit SHA
+type CommitSource interface {
+	// ResolveCommit returns the full SHA of the commit the ref name points at
+	ResolveCommit(ctx context.Context, ref *DocumentRef, name string) (string, error)
+}
+
+// IsCommitSHA reports whether a ref name is a full commit SHA, which unlike tags and branches never moves
+func IsCommitSHA(name string) bool {
+	return commitSHAPattern.MatchString(strings.ToLower(name))
+}
+
+// PinRef points a document at the commit a tag, branch or commit SHA currently resolves to.
+// The document's Branch becomes that full SHA, so its content never changes.
+func PinRef(ctx context.Context, source ContentSource, ref *DocumentRef, pinned string) error {
+	if source.Name() == "local" {
+		return fmt.Errorf("local documents have no refs")
+	}
+	if !pinnedRefPattern.MatchString(pinned) || strings.Contains(pinned, "..") || strings.HasSuffix(pinned, "/") {
+		return fmt.Errorf("invalid ref: %s", pinned)
+	}
+
+	sha, err := resolveCommit(ctx, source, ref, pinned)
+	if err != nil {
+		return err
+	}
+
+	ref.Branch = sha
+	ref.Pinned = true
+	ref.RefKind = "commit"
+	return nil
+}
+
+// resolveCommit returns the full SHA of a ref name; full SHAs are taken as they are
+func resolveCommit(ctx context.Context, source ContentSource, ref *DocumentRef, name string) (string, error) {
+	if IsCommitSHA(name) {
+		return strings.ToLower(name), nil
+	}
+
+	cs, ok := source.(CommitSource)
+	if !ok {
+		return "", fmt.Errorf("%s documents cannot be pinned to a ref", source.Name())
+	}
+
+	cacheKey := fmt.Sprintf("commit:%s:%s/%s/%s:%s", source.Name(), ref.Host, ref.Owner, ref.Repo, name)
+	if cached, err := cache.GetCachedContent(ctx, cacheKey); err == nil && IsCommitSHA(cached.Content) {
+		return cached.Content, nil
+	}
+
+	sha, err := cs.ResolveCommit(ctx, ref, name)
+	if err != nil {
+		return "", fmt.Errorf("resolving ref %s: %w", name, err)
+	}
+	if !IsCommitSHA(sha) {
+		return "", fmt.Errorf("resolving ref %s: unexpected commit id %q", name, sha)
+	}
+	sha = strings.ToLower(sha)
+
+	if err := cache.SetCachedContentFor(ctx, cacheKey, &cache.CachedContent{
+		Content:     sha,
+		LastUpdated: time.Now(),
+	}, commitResolutionTTL); err != nil {
+		fmt.Printf("Warning: failed to cache resolved ref: %v\n", err)
+	}
+	return sha, nil
+}
+
+// ResolveCommit reads the commit a ref points at from the commits API
+func (s *GitHubSource) ResolveCommit(ctx context.Context, ref *DocumentRef, name string) (string, error) {
+	body, err := makeGitHubRequest(ctx, fmt.Sprintf("https://api.github.com/repos/%s/%s/commits/%s",
+		ref.Owner, ref.Repo, url.PathEscape(name)))
+	if err != nil {
+		return "", err
+	}
+
+	var commit GitHubCommit
+	if err := json.Unmarshal(body, &commit); err != nil {
+		return "", fmt.Errorf("error unmarshalling GitHub commit response: %v", err)
+	}
+	return commit.SHA, nil
+}
+
+// ResolveCommit reads the commit a ref points at from the commits API
+func (s *GitLabSource) ResolveCommit(ctx context.Context, ref *DocumentRef, name string) (string, error) {
+	apiURL := fmt.Sprintf("%s/repository/commits/%s", s.projectAPIURL(ref), url.PathEscape(name))
+
+	resp, err := makeSourceRequest(ctx, apiURL, "GitLab API", s.headers(), Validators{})
+	if err != nil {
+		return "", err
+	}
+
+	var commit gitLabCommit
+	if err := json.Unmarshal(resp.body, &commit); err != nil {
+		return "", fmt.Errorf("error unmarshalling GitLab commit response: %v", err)
+	}
+	return commit.ID, nil
+}
+
+// ResolveCommit reads the newest commit of the ref from the commits API
+func (s *GiteaSource) ResolveCommit(ctx context.Context, ref *DocumentRef, name string) (string, error) {
+	apiURL := fmt.Sprintf("%s/commits?sha=%s&limit=1", s.repoAPIURL(ref), url.QueryEscape(name))
+
+	resp, err := makeSourceRequest(ctx, apiURL, "Gitea API", s.headers(), Validators{})
+	if err != nil {
+		return "", err
+	}
+
+	var commits []GitHubCommit
+	if err := json.Unmarshal(resp.body, &commits); err != nil {
+		return "", fmt.Errorf("error unmarshalling Gitea commits response: %v", err)
+	}
+	if len(commits) == 0 {
+		return "", fmt.Errorf("no commits found")
+	}
+	return commits[0].SHA, nil
+}
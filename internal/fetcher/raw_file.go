@@ -0,0 +1,253 @@
+package fetcher
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"prosamik-backend/internal/auth"
+	"strings"
+	"time"
+)
+
+// maxRawFileSize caps the size of raw files downloaded from content sources
+const maxRawFileSize = 25 << 20
+
+// RawFile is a file downloaded from one of the raw file URLs of a content source
+type RawFile struct {
+	Body        []byte
+	ContentType string
+}
+
+// rawFileSource is implemented by sources that can fetch the files behind their raw file URLs
+type rawFileSource interface {
+	// rawFileRef parses a raw file URL into the repository file it serves; ok is false when
+	// u is not a raw file URL of the source
+	rawFileRef(u *url.URL) (ref *DocumentRef, ok bool)
+	// fetchRawFile fetches the file behind a raw file URL of the source
+	fetchRawFile(ctx context.Context, u *url.URL) (*RawFile, error)
+}
+
+// ErrNotRawFileURL is returned for URLs that do not belong to any content source
+var ErrNotRawFileURL = errors.New("URL is not a raw file URL of a content source")
+
+// ResolveRawFile selects the content source serving a raw file URL and parses the repository
+// file it points at, so the document policy can be checked before the file is fetched
+func ResolveRawFile(rawURL string) (ContentSource, *DocumentRef, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.User != nil {
+		return nil, nil, ErrNotRawFileURL
+	}
+
+	for _, source := range registeredSources() {
+		rs, ok := source.(rawFileSource)
+		if !ok {
+			continue
+		}
+		if ref, ok := rs.rawFileRef(u); ok {
+			ref.Source = source.Name()
+			return source, ref, nil
+		}
+	}
+
+	return nil, nil, ErrNotRawFileURL
+}
+
+// FetchRawFile downloads a raw file URL of the source ResolveRawFile selected, authenticating
+// with the credentials of that source. Callers check the document policy first.
+func FetchRawFile(ctx context.Context, source ContentSource, rawURL string) (*RawFile, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, ErrNotRawFileURL
+	}
+	rs, ok := source.(rawFileSource)
+	if !ok {
+		return nil, ErrNotRawFileURL
+	}
+	if _, ok := rs.rawFileRef(u); !ok {
+		return nil, ErrNotRawFileURL
+	}
+	return rs.fetchRawFile(ctx, u)
+}
+
+// fetchRawHTTPFile downloads a raw file over HTTP, adding the given headers when their values are not empty
+func fetchRawHTTPFile(ctx context.Context, u *url.URL, sourceName string, headers map[string]string) (*RawFile, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", u.String(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("creating request: %w", err)
+	}
+	for name, value := range headers {
+		if value != "" {
+			req.Header.Set(name, value)
+		}
+	}
+
+	client := &http.Client{Timeout: 30 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("making request: %w", err)
+	}
+	defer func() {
+		if cerr := resp.Body.Close(); cerr != nil {
+			fmt.Printf("warning: failed to close response body: %v\n", cerr)
+		}
+	}()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s returned non-OK status: %s", sourceName, resp.Status)
+	}
+
+	body, err := readLimited(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	return &RawFile{Body: body, ContentType: resp.Header.Get("Content-Type")}, nil
+}
+
+// readLimited reads a raw file, failing when it exceeds maxRawFileSize
+func readLimited(r io.Reader) ([]byte, error) {
+	body, err := io.ReadAll(io.LimitReader(r, maxRawFileSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("reading response body: %w", err)
+	}
+	if len(body) > maxRawFileSize {
+		return nil, fmt.Errorf("file exceeds %d bytes", maxRawFileSize)
+	}
+	return body, nil
+}
+
+// rawFileRef parses raw.githubusercontent.com/<owner>/<repo>/<ref>/<path>
+func (s *GitHubSource) rawFileRef(u *url.URL) (*DocumentRef, bool) {
+	if u.Scheme != "https" || strings.ToLower(u.Host) != "raw.githubusercontent.com" {
+		return nil, false
+	}
+	segments := splitPath(u.Path)
+	if len(segments) < 4 {
+		return nil, false
+	}
+	return &DocumentRef{
+		Host:   "github.com",
+		Owner:  segments[0],
+		Repo:   segments[1],
+		Branch: segments[2],
+		Path:   strings.Join(segments[3:], "/"),
+	}, true
+}
+
+func (s *GitHubSource) fetchRawFile(ctx context.Context, u *url.URL) (*RawFile, error) {
+	// The token is optional here so public images still load without one
+	headers := map[string]string{}
+	if token := auth.GetGitHubToken(); token != "" {
+		headers["Authorization"] = "Bearer " + token
+	}
+	return fetchRawHTTPFile(ctx, u, "GitHub", headers)
+}
+
+// rawFileRef parses <host>/<namespace>/<repo>/-/raw/<ref>/<path>
+func (s *GitLabSource) rawFileRef(u *url.URL) (*DocumentRef, bool) {
+	if !s.Matches(u) {
+		return nil, false
+	}
+	project, file, found := strings.Cut(u.Path, "/-/raw/")
+	if !found {
+		return nil, false
+	}
+	projectSegments, fileSegments := splitPath(project), splitPath(file)
+	if len(projectSegments) < 2 || len(fileSegments) < 2 {
+		return nil, false
+	}
+	return &DocumentRef{
+		Host:   strings.ToLower(u.Host),
+		Owner:  strings.Join(projectSegments[:len(projectSegments)-1], "/"),
+		Repo:   projectSegments[len(projectSegments)-1],
+		Branch: fileSegments[0],
+		Path:   strings.Join(fileSegments[1:], "/"),
+	}, true
+}
+
+func (s *GitLabSource) fetchRawFile(ctx context.Context, u *url.URL) (*RawFile, error) {
+	return fetchRawHTTPFile(ctx, u, "GitLab", s.headers())
+}
+
+// rawFileRef parses <host>/<owner>/<repo>/raw/[branch|tag|commit/]<ref>/<path>
+func (s *GiteaSource) rawFileRef(u *url.URL) (*DocumentRef, bool) {
+	if !s.Matches(u) {
+		return nil, false
+	}
+	segments := splitPath(u.Path)
+	if len(segments) < 5 || segments[2] != "raw" {
+		return nil, false
+	}
+	rest, kind := segments[3:], ""
+	switch rest[0] {
+	case "branch", "tag", "commit":
+		rest, kind = rest[1:], rest[0]
+	}
+	if len(rest) < 2 {
+		return nil, false
+	}
+	return &DocumentRef{
+		Host:    strings.ToLower(u.Host),
+		Owner:   segments[0],
+		Repo:    segments[1],
+		Branch:  rest[0],
+		Path:    strings.Join(rest[1:], "/"),
+		RefKind: kind,
+	}, true
+}
+
+func (s *GiteaSource) fetchRawFile(ctx context.Context, u *url.URL) (*RawFile, error) {
+	return fetchRawHTTPFile(ctx, u, "Gitea", s.headers())
+}
+
+// rawFileRef maps files behind LOCAL_CONTENT_BASE_URL onto the content directory
+func (s *LocalSource) rawFileRef(u *url.URL) (*DocumentRef, bool) {
+	base := strings.TrimSuffix(s.baseURL, "/") + "/"
+	if s.baseURL == "" || !strings.HasPrefix(u.String(), base) {
+		return nil, false
+	}
+
+	rel, _, _ := strings.Cut(strings.TrimPrefix(u.String(), base), "?")
+	rel, err := url.PathUnescape(rel)
+	if err != nil {
+		return nil, false
+	}
+
+	// Clean against the root so the path can never escape the content directory
+	return &DocumentRef{
+		Host:  "local",
+		Owner: "local",
+		Repo:  filepath.Base(s.root),
+		Path:  strings.TrimPrefix(path.Clean("/"+rel), "/"),
+	}, true
+}
+
+// fetchRawFile reads files behind LOCAL_CONTENT_BASE_URL straight from the content directory
+func (s *LocalSource) fetchRawFile(_ context.Context, u *url.URL) (*RawFile, error) {
+	ref, _ := s.rawFileRef(u)
+	resolved, err := s.resolvePath(ref.Path)
+	if err != nil {
+		return nil, fmt.Errorf("reading local file: %w", err)
+	}
+	f, err := os.Open(resolved)
+	if err != nil {
+		return nil, fmt.Errorf("reading local file: %w", err)
+	}
+	defer func() {
+		if cerr := f.Close(); cerr != nil {
+			fmt.Printf("warning: failed to close local file: %v\n", cerr)
+		}
+	}()
+
+	body, err := readLimited(f)
+	if err != nil {
+		return nil, err
+	}
+	return &RawFile{Body: body}, nil
+}
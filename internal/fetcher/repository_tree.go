@@ -0,0 +1,37 @@
+package fetcher
+
+import (
+	"context"
+	"errors"
+	"strings"
+)
+
+// TreeEntry is a file in a repository
+type TreeEntry struct {
+	Path string // File path within the repository
+	SHA  string // Blob SHA of the content; empty when the source has none
+}
+
+// TreeSource is implemented by sources that can list the files of a repository
+type TreeSource interface {
+	// FetchTree lists the files below dir at the ref's branch. Truncated reports
+	// that the source returned only part of a very large tree.
+	FetchTree(ctx context.Context, ref *DocumentRef, dir string) (entries []TreeEntry, truncated bool, err error)
+}
+
+// ErrTreeUnsupported is returned for sources that cannot list repository files
+var ErrTreeUnsupported = errors.New("content source cannot list repository files")
+
+// FetchTree lists the files below dir at the ref's branch
+func FetchTree(ctx context.Context, source ContentSource, ref *DocumentRef, dir string) ([]TreeEntry, bool, error) {
+	ts, ok := source.(TreeSource)
+	if !ok {
+		return nil, false, ErrTreeUnsupported
+	}
+	return ts.FetchTree(ctx, ref, strings.Trim(dir, "/"))
+}
+
+// inDir reports whether a repository file lies below dir; every file lies below the root
+func inDir(filePath, dir string) bool {
+	return dir == "" || strings.HasPrefix(filePath, dir+"/")
+}
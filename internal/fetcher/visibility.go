@@ -0,0 +1,89 @@
+package fetcher
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"prosamik-backend/internal/cache"
+	"strconv"
+	"time"
+)
+
+// VisibilitySource is implemented by sources that can tell private repositories apart
+type VisibilitySource interface {
+	// IsPrivate reports whether reading the repository requires credentials
+	IsPrivate(ctx context.Context, ref *DocumentRef) (bool, error)
+}
+
+// RepositoryPrivate reports whether the document's repository is private. Sources without
+// visibility are public. Like default branches, visibility is cached per repository.
+func RepositoryPrivate(ctx context.Context, source ContentSource, ref *DocumentRef) (bool, error) {
+	vs, ok := source.(VisibilitySource)
+	if !ok {
+		return false, nil
+	}
+
+	cacheKey := fmt.Sprintf("visibility:%s:%s/%s/%s", source.Name(), ref.Host, ref.Owner, ref.Repo)
+	if cached, err := cache.GetCachedContent(ctx, cacheKey); err == nil {
+		if private, err := strconv.ParseBool(cached.Content); err == nil {
+			return private, nil
+		}
+	}
+
+	private, err := vs.IsPrivate(ctx, ref)
+	if err != nil {
+		return false, err
+	}
+
+	if err := cache.SetCachedContent(ctx, cacheKey, &cache.CachedContent{
+		Content:     strconv.FormatBool(private),
+		LastUpdated: time.Now(),
+	}); err != nil {
+		fmt.Printf("Warning: failed to cache repository visibility: %v\n", err)
+	}
+	return private, nil
+}
+
+// IsPrivate reads the private flag of the repository API
+func (s *GitHubSource) IsPrivate(ctx context.Context, ref *DocumentRef) (bool, error) {
+	body, err := makeGitHubRequest(ctx, fmt.Sprintf("https://api.github.com/repos/%s/%s", ref.Owner, ref.Repo))
+	if err != nil {
+		return false, err
+	}
+
+	var repository GitHubRepository
+	if err := json.Unmarshal(body, &repository); err != nil {
+		return false, fmt.Errorf("error unmarshalling GitHub repository response: %v", err)
+	}
+	return repository.Private, nil
+}
+
+// IsPrivate reads the private flag of the repository API
+func (s *GiteaSource) IsPrivate(ctx context.Context, ref *DocumentRef) (bool, error) {
+	resp, err := makeSourceRequest(ctx, s.repoAPIURL(ref), "Gitea API", s.headers(), Validators{})
+	if err != nil {
+		return false, err
+	}
+
+	var repository GitHubRepository
+	if err := json.Unmarshal(resp.body, &repository); err != nil {
+		return false, fmt.Errorf("error unmarshalling Gitea repository response: %v", err)
+	}
+	return repository.Private, nil
+}
+
+// IsPrivate treats internal projects, which need a login, as private
+func (s *GitLabSource) IsPrivate(ctx context.Context, ref *DocumentRef) (bool, error) {
+	resp, err := makeSourceRequest(ctx, s.projectAPIURL(ref), "GitLab API", s.headers(), Validators{})
+	if err != nil {
+		return false, err
+	}
+
+	var project struct {
+		Visibility string `json:"visibility"`
+	}
+	if err := json.Unmarshal(resp.body, &project); err != nil {
+		return false, fmt.Errorf("error unmarshalling GitLab project response: %v", err)
+	}
+	return project.Visibility != "public", nil
+}
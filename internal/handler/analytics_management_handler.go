@@ -9,7 +9,9 @@ import (
 	"log"
 	"net/http"
 	"prosamik-backend/internal/cache"
+	"prosamik-backend/internal/fetcher"
 	"prosamik-backend/internal/repository"
+	"prosamik-backend/internal/warmer"
 	"sort"
 	"time"
 )
@@ -293,6 +295,8 @@ func HandleCacheMonitoring(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "Failed to get cache statistics", http.StatusInternalServerError)
 		return
 	}
+	cacheStats["github_rate_limit"] = fetcher.GitHubRateLimit()
+	cacheStats["warmer"] = warmer.CurrentStatus()
 
 	data := PageData{
 		Page: "cache-monitoring",
@@ -315,6 +319,7 @@ func HandleCacheStats(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "Internal server error", http.StatusInternalServerError)
 		return
 	}
+	stats["github_rate_limit"] = fetcher.GitHubRateLimit()
 
 	data := PageData{
 		Page: "cache-monitoring",
@@ -0,0 +1,185 @@
+package handler
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"prosamik-backend/internal/auth"
+	"prosamik-backend/internal/middleware"
+	"prosamik-backend/internal/repository"
+	"prosamik-backend/pkg/models"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	defaultAPIKeyDailyQuota = 10000 // Matches the column default of api_keys
+	defaultAPIKeyRateLimit  = 300
+)
+
+type APIKeyManagementData struct {
+	Keys              []*models.APIKey
+	Anonymous         middleware.AnonymousTier
+	AnonymousRequests int // Anonymous requests of the current UTC day
+	AnonymousClients  int // Clients that made them
+}
+
+// APIKeyCreatedData is shown once after a key is created; the key can't be recovered afterwards
+type APIKeyCreatedData struct {
+	Error string
+	Key   string
+	Name  string
+}
+
+func HandleAPIKeyManagement(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	data, err := apiKeyManagementData()
+	if err != nil {
+		log.Printf("Error fetching API keys: %v", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	err = templates.ExecuteTemplate(w, "base", PageData{
+		Page: "api-keys",
+		Data: data,
+	})
+	if err != nil {
+		log.Printf("Template error: %v", err)
+		http.Error(w, "Failed to render template", http.StatusInternalServerError)
+	}
+}
+
+// HandleAPIKeyTable renders the table of keys with their usage, for refreshing it in place
+func HandleAPIKeyTable(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	writeAPIKeyTable(w)
+}
+
+func HandleAPIKeyCreate(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	writeMessage := func(data APIKeyCreatedData) {
+		if err := templates.ExecuteTemplate(w, "api-key-created", data); err != nil {
+			log.Printf("Template error: %v", err)
+		}
+	}
+
+	name := strings.TrimSpace(r.FormValue("name"))
+	if name == "" {
+		writeMessage(APIKeyCreatedData{Error: "Name is required"})
+		return
+	}
+
+	dailyQuota, err := formLimit(r.FormValue("daily_quota"), defaultAPIKeyDailyQuota)
+	if err != nil {
+		writeMessage(APIKeyCreatedData{Error: "Daily quota must be a number of 0 or more"})
+		return
+	}
+	rateLimit, err := formLimit(r.FormValue("rate_limit"), defaultAPIKeyRateLimit)
+	if err != nil {
+		writeMessage(APIKeyCreatedData{Error: "Rate limit must be a number of 0 or more"})
+		return
+	}
+
+	key, prefix, hash, err := auth.GenerateAPIKey()
+	if err != nil {
+		log.Printf("Error generating API key: %v", err)
+		writeMessage(APIKeyCreatedData{Error: "Failed to generate API key"})
+		return
+	}
+
+	apiKey := &models.APIKey{
+		Name:       name,
+		Prefix:     prefix,
+		DailyQuota: dailyQuota,
+		RateLimit:  rateLimit,
+	}
+	if err := repository.NewAPIKeyRepository().CreateAPIKey(apiKey, hash); err != nil {
+		log.Printf("Error creating API key: %v", err)
+		writeMessage(APIKeyCreatedData{Error: "Failed to create API key"})
+		return
+	}
+
+	writeMessage(APIKeyCreatedData{Key: key, Name: name})
+}
+
+func HandleAPIKeyRevoke(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	segments := strings.Split(r.URL.Path, "/")
+	id, err := strconv.ParseInt(segments[len(segments)-1], 10, 64)
+	if err != nil {
+		log.Printf("Invalid ID format: %v", err)
+		http.Error(w, "Invalid ID format", http.StatusBadRequest)
+		return
+	}
+
+	if err := repository.NewAPIKeyRepository().RevokeAPIKey(id); err != nil {
+		log.Printf("Error revoking API key: %v", err)
+		http.Error(w, "Failed to revoke API key", http.StatusInternalServerError)
+		return
+	}
+	middleware.DocumentLimiter.ForgetKeys()
+
+	writeAPIKeyTable(w)
+}
+
+// writeAPIKeyTable renders the "api-key-table" fragment
+func writeAPIKeyTable(w http.ResponseWriter) {
+	data, err := apiKeyManagementData()
+	if err != nil {
+		log.Printf("Error fetching API keys: %v", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	if err := templates.ExecuteTemplate(w, "api-key-table", data); err != nil {
+		log.Printf("Template error: %v", err)
+		http.Error(w, "Failed to render template", http.StatusInternalServerError)
+	}
+}
+
+// apiKeyManagementData collects the keys with their usage and the usage of the anonymous tier
+func apiKeyManagementData() (APIKeyManagementData, error) {
+	keys, err := repository.NewAPIKeyRepository().GetAllAPIKeys(time.Now().UTC())
+	if err != nil {
+		return APIKeyManagementData{}, err
+	}
+
+	requests, clients := middleware.DocumentLimiter.AnonymousUsage()
+	return APIKeyManagementData{
+		Keys:              keys,
+		Anonymous:         middleware.DocumentLimiter.AnonymousTier(),
+		AnonymousRequests: requests,
+		AnonymousClients:  clients,
+	}, nil
+}
+
+// formLimit parses a non-negative limit of the key form; empty values use the default
+func formLimit(value string, fallback int) (int, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return fallback, nil
+	}
+	limit, err := strconv.Atoi(value)
+	if err != nil || limit < 0 {
+		return 0, fmt.Errorf("invalid limit: %s", value)
+	}
+	return limit, nil
+}
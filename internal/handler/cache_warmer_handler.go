@@ -0,0 +1,56 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"prosamik-backend/internal/cache"
+	"prosamik-backend/internal/fetcher"
+	"prosamik-backend/internal/warmer"
+)
+
+// WarmDocument makes sure the JSON rendering of a document, as requested by the frontend, is cached
+// and fresh. Stale entries are revalidated conditionally and fresh ones are left alone.
+func WarmDocument(ctx context.Context, url string) error {
+	cached, err := cache.GetCachedContent(ctx, documentCacheKey(url, formatJSON))
+	if err == nil && cached != nil && !cached.IsStale() {
+		return nil
+	}
+	if err != nil && !errors.Is(err, cache.ErrNilCache) {
+		fmt.Printf("Warning: failed to read cached document %s: %v\n", url, err)
+	}
+
+	source, ref, err := fetcher.ResolveSource(url)
+	if err != nil {
+		return err
+	}
+
+	if cached != nil {
+		_, err = loadDocument(ctx, url, formatJSON, source, ref, cached)
+		return err
+	}
+	_, err = loadDocumentShared(ctx, url, formatJSON, source, ref)
+	return err
+}
+
+// HandleCacheWarmer renders the cache warmer status for the monitoring page.
+// A POST starts a run first.
+func HandleCacheWarmer(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	started := r.Method == http.MethodPost && warmer.Trigger()
+	status := warmer.CurrentStatus()
+	if started {
+		// The run starts in the background; show it as running right away
+		status.Running = true
+	}
+
+	if err := templates.ExecuteTemplate(w, "cache-warmer", status); err != nil {
+		fmt.Printf("Template error: %v\n", err)
+		http.Error(w, "Failed to render template", http.StatusInternalServerError)
+	}
+}
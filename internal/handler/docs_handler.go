@@ -0,0 +1,430 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"path"
+	"prosamik-backend/internal/cache"
+	"prosamik-backend/internal/fetcher"
+	"prosamik-backend/internal/parser"
+	"prosamik-backend/internal/policy"
+	"prosamik-backend/pkg/models"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	maxDocsPages        = 200    // Pages listed in a documentation site at most
+	maxDocsTitleFetches = 20     // Uncached pages fetched for their titles per navigation at most
+	docsConcurrency     = 4      // Pages fetched at once to read their titles
+	docsIndexFormat     = "docs" // Marks docs navigations in the repository index
+)
+
+// docsIndexNames are the files presenting a folder, matched case-insensitively
+var docsIndexNames = map[string]bool{"readme.md": true, "index.md": true, "readme.markdown": true, "index.markdown": true}
+
+// errNoDocs is returned for folders without any Markdown files
+var errNoDocs = errors.New("no Markdown documents found in the folder")
+
+// docsPage is a document of a documentation site
+type docsPage struct {
+	fetcher.TreeEntry
+	rel   string // Path relative to the docs folder
+	title string
+}
+
+// docsFolder is a folder of a documentation site while its navigation is built
+type docsFolder struct {
+	index   *models.DocsNavItem
+	pages   map[string]models.DocsNavItem
+	folders map[string]*docsFolder
+}
+
+// HandleDocs returns the navigation of a repository folder as a documentation site.
+// With ?page=<path relative to the folder> the rendered page and its previous and next pages are added.
+func HandleDocs(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	url := r.URL.Query().Get("url")
+	if url == "" {
+		http.Error(w, "URL parameter is missing", http.StatusBadRequest)
+		return
+	}
+
+	source, ref, err := fetcher.ResolveSource(url)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Error resolving content source: %v", err), http.StatusBadRequest)
+		return
+	}
+	if !allowDocument(w, r, source, ref) {
+		return
+	}
+
+	site, err := loadDocsSite(r.Context(), url, source, ref)
+	if err != nil {
+		writeDocsError(w, r, url, err)
+		return
+	}
+
+	if page := strings.Trim(r.URL.Query().Get("page"), "/"); page != "" {
+		pages := flattenDocsNav(site.Nav)
+		current := -1
+		for i := range pages {
+			if pages[i].Path == page {
+				current = i
+				break
+			}
+		}
+		if current < 0 {
+			http.Error(w, fmt.Sprintf("Page %s is not part of the documentation", page), http.StatusNotFound)
+			return
+		}
+
+		// Private repositories only serve the pages registered one by one
+		pageSource, pageRef, err := fetcher.ResolveSource(pages[current].URL)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("Error resolving content source: %v", err), http.StatusBadGateway)
+			return
+		}
+		if !allowDocument(w, r, pageSource, pageRef) {
+			return
+		}
+
+		doc, err := docsPageDocument(r.Context(), pages[current].URL)
+		if err != nil {
+			writeDocsError(w, r, pages[current].URL, err)
+			return
+		}
+		site.Page = doc
+		if current > 0 {
+			site.Prev = &pages[current-1]
+		}
+		if current < len(pages)-1 {
+			site.Next = &pages[current+1]
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(site); err != nil {
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+	}
+}
+
+// writeDocsError answers a documentation site that could not be loaded
+func writeDocsError(w http.ResponseWriter, r *http.Request, url string, err error) {
+	switch {
+	case r.Context().Err() != nil, writeRateLimited(w, err):
+	case errors.Is(err, fetcher.ErrTreeUnsupported):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, errNoDocs):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	default:
+		fmt.Printf("Error loading docs %s: %v\n", url, err)
+		http.Error(w, fmt.Sprintf("Error loading docs: %v", err), http.StatusBadGateway)
+	}
+}
+
+// loadDocsSite returns the navigation of the folder the URL points at, listing its Markdown files
+// through the source's tree. Navigations are cached and invalidated by pushes below the folder.
+func loadDocsSite(ctx context.Context, url string, source fetcher.ContentSource,
+	ref *fetcher.DocumentRef) (*models.DocsSite, error) {
+	key := fmt.Sprintf("docs:%s:nav:%s", documentCacheVersion, url)
+	if cached, err := cache.GetCachedContent(ctx, key); err == nil && cached != nil {
+		var site models.DocsSite
+		if err := json.Unmarshal([]byte(cached.Content), &site); err == nil {
+			return &site, nil
+		}
+	}
+
+	if _, ok := source.(fetcher.TreeSource); !ok {
+		return nil, fetcher.ErrTreeUnsupported
+	}
+	if err := fetcher.ResolveBranch(ctx, source, ref); err != nil {
+		return nil, fmt.Errorf("resolving default branch: %w", err)
+	}
+
+	// Folder URLs point at the folder's README, so the site is the folder of the document
+	root := path.Dir(ref.Path)
+	if root == "." {
+		root = ""
+	}
+
+	entries, truncated, err := fetcher.FetchTree(ctx, source, ref, root)
+	if err != nil {
+		return nil, err
+	}
+
+	var pages []docsPage
+	for _, entry := range entries {
+		if isMarkdownPath(entry.Path) {
+			rel := strings.TrimPrefix(strings.TrimPrefix(entry.Path, root), "/")
+			pages = append(pages, docsPage{TreeEntry: entry, rel: rel})
+		}
+	}
+	if pages, err = allowedDocsPages(ctx, source, ref, pages); err != nil {
+		return nil, err
+	}
+	if len(pages) == 0 {
+		return nil, errNoDocs
+	}
+	sort.Slice(pages, func(i, j int) bool { return pages[i].rel < pages[j].rel })
+	if len(pages) > maxDocsPages {
+		pages = pages[:maxDocsPages]
+		truncated = true
+	}
+
+	titled, err := readDocsTitles(ctx, source, ref, pages)
+	if err != nil {
+		return nil, err
+	}
+
+	site := &models.DocsSite{
+		URL:       url,
+		Title:     ref.Repo,
+		Nav:       buildDocsNav(pages, source, ref),
+		Truncated: truncated,
+	}
+
+	data, err := json.Marshal(site)
+	if err != nil {
+		return nil, fmt.Errorf("marshaling docs navigation: %w", err)
+	}
+	// Navigations with titles still to read are built again soon, reading the next pages
+	ttl := cache.TTL
+	if !titled {
+		ttl = cache.PartialTTL
+	}
+	if err := cache.SetCachedContentFor(ctx, key, &cache.CachedContent{
+		Content:     string(data),
+		LastUpdated: time.Now(),
+	}, ttl); err != nil {
+		fmt.Printf("Warning: failed to cache docs navigation: %v\n", err)
+	} else if err := cache.IndexDocument(ctx, ref.RepositoryKey(), cache.DocumentIndexEntry{
+		Key:    key,
+		URL:    url,
+		Format: docsIndexFormat,
+		Branch: ref.Branch,
+		Path:   root,
+	}); err != nil {
+		fmt.Printf("Warning: failed to index docs navigation: %v\n", err)
+	}
+
+	return site, nil
+}
+
+// allowedDocsPages drops the pages the document policy refuses, so that private repositories only
+// list, and have titles read for, the pages registered one by one
+func allowedDocsPages(ctx context.Context, source fetcher.ContentSource, ref *fetcher.DocumentRef,
+	pages []docsPage) ([]docsPage, error) {
+	var allowed []docsPage
+	for _, page := range pages {
+		pageRef := *ref
+		pageRef.Path = page.Path
+		err := policy.CheckDocument(ctx, source, &pageRef)
+		switch {
+		case err == nil:
+			allowed = append(allowed, page)
+		case !errors.Is(err, policy.ErrDenied):
+			return nil, err
+		}
+	}
+	return allowed, nil
+}
+
+// readDocsTitles fills in the title of every page from its front matter or first heading.
+// Titles are cached by blob SHA, so unchanged pages are not fetched again. At most
+// maxDocsTitleFetches uncached pages are fetched per navigation; the others are titled
+// after their file name until a later build reads them, and titled is false.
+func readDocsTitles(ctx context.Context, source fetcher.ContentSource, ref *fetcher.DocumentRef,
+	pages []docsPage) (titled bool, err error) {
+	var cold []*docsPage
+	for i := range pages {
+		if title, ok := cachedDocsTitle(ctx, &pages[i]); ok {
+			pages[i].title = title
+		} else {
+			cold = append(cold, &pages[i])
+		}
+	}
+	titled = len(cold) <= maxDocsTitleFetches
+	if !titled {
+		cold = cold[:maxDocsTitleFetches]
+	}
+
+	var mu sync.Mutex
+	var rateLimitErr error
+
+	sem := make(chan struct{}, docsConcurrency)
+	var wg sync.WaitGroup
+	for _, page := range cold {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(page *docsPage) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			title, err := fetchDocsTitle(ctx, source, ref, page)
+			if err != nil {
+				var limited *fetcher.RateLimitError
+				if errors.As(err, &limited) {
+					mu.Lock()
+					rateLimitErr = err
+					mu.Unlock()
+					return
+				}
+				fmt.Printf("Warning: failed to read the title of %s: %v\n", page.Path, err)
+			}
+			page.title = title
+		}(page)
+	}
+	wg.Wait()
+
+	for i := range pages {
+		if pages[i].title == "" {
+			name := path.Base(pages[i].rel)
+			pages[i].title = strings.TrimSuffix(name, path.Ext(name))
+		}
+	}
+	return titled, rateLimitErr
+}
+
+// docsTitleKey returns the cache key of the title of a blob
+func docsTitleKey(sha string) string {
+	return fmt.Sprintf("docs:%s:title:%s", documentCacheVersion, sha)
+}
+
+// cachedDocsTitle returns the cached title of a page, which is empty when the page has none
+func cachedDocsTitle(ctx context.Context, page *docsPage) (string, bool) {
+	if page.SHA == "" {
+		return "", false
+	}
+	cached, err := cache.GetCachedContent(ctx, docsTitleKey(page.SHA))
+	if err != nil || cached == nil {
+		return "", false
+	}
+	return cached.Content, true
+}
+
+// fetchDocsTitle fetches a page to read its title, which is empty when the page has none
+func fetchDocsTitle(ctx context.Context, source fetcher.ContentSource, ref *fetcher.DocumentRef,
+	page *docsPage) (string, error) {
+	pageRef := *ref
+	pageRef.Path = page.Path
+	result, err := source.FetchContent(ctx, &pageRef, fetcher.Validators{})
+	if err != nil {
+		return "", err
+	}
+	title := parser.DocumentTitle(page.Path, result.Content)
+
+	// A blob SHA always names the same content, so its title never changes
+	if page.SHA != "" {
+		if err := cache.SetCachedDocument(ctx, docsTitleKey(page.SHA), &cache.CachedContent{
+			Content:     title,
+			LastUpdated: time.Now(),
+			Pinned:      true,
+		}); err != nil {
+			fmt.Printf("Warning: failed to cache docs title: %v\n", err)
+		}
+	}
+	return title, nil
+}
+
+// buildDocsNav nests the pages by folder. A folder's README or index page presents the folder,
+// and the top-level one comes first; everything else is sorted by name.
+func buildDocsNav(pages []docsPage, source fetcher.ContentSource, ref *fetcher.DocumentRef) []models.DocsNavItem {
+	root := newDocsFolder()
+	for _, page := range pages {
+		item := models.DocsNavItem{Title: page.title, Path: page.rel, URL: source.BlobURL(ref, page.Path)}
+
+		folder := root
+		segments := strings.Split(page.rel, "/")
+		for _, segment := range segments[:len(segments)-1] {
+			if folder.folders[segment] == nil {
+				folder.folders[segment] = newDocsFolder()
+			}
+			folder = folder.folders[segment]
+		}
+
+		name := segments[len(segments)-1]
+		if docsIndexNames[strings.ToLower(name)] && folder.index == nil {
+			folder.index = &item
+		} else {
+			folder.pages[name] = item
+		}
+	}
+
+	nav := root.items()
+	if root.index != nil {
+		nav = append([]models.DocsNavItem{*root.index}, nav...)
+	}
+	return nav
+}
+
+func newDocsFolder() *docsFolder {
+	return &docsFolder{pages: make(map[string]models.DocsNavItem), folders: make(map[string]*docsFolder)}
+}
+
+// items returns the navigation of the folder's pages and subfolders, sorted by name
+func (f *docsFolder) items() []models.DocsNavItem {
+	names := make([]string, 0, len(f.pages)+len(f.folders))
+	for name := range f.pages {
+		names = append(names, name)
+	}
+	for name := range f.folders {
+		if _, clash := f.pages[name]; !clash {
+			names = append(names, name)
+		}
+	}
+	sort.Slice(names, func(i, j int) bool { return strings.ToLower(names[i]) < strings.ToLower(names[j]) })
+
+	var items []models.DocsNavItem
+	for _, name := range names {
+		if page, ok := f.pages[name]; ok {
+			items = append(items, page)
+		}
+		folder, ok := f.folders[name]
+		if !ok {
+			continue
+		}
+		item := models.DocsNavItem{Title: name}
+		if folder.index != nil {
+			item = *folder.index
+		}
+		item.Children = folder.items()
+		items = append(items, item)
+	}
+	return items
+}
+
+// flattenDocsNav lists the pages of a navigation in reading order, without their children
+func flattenDocsNav(items []models.DocsNavItem) []models.DocsNavItem {
+	var pages []models.DocsNavItem
+	for _, item := range items {
+		children := item.Children
+		item.Children = nil
+		if item.URL != "" {
+			pages = append(pages, item)
+		}
+		pages = append(pages, flattenDocsNav(children)...)
+	}
+	return pages
+}
+
+// docsPageDocument returns the rendered page the same way /md does
+func docsPageDocument(ctx context.Context, url string) (*models.MarkdownDocument, error) {
+	entry, err := cache.GetCachedContent(ctx, documentCacheKey(url, formatJSON))
+	if err == nil && entry != nil {
+		if entry.IsStale() {
+			revalidateInBackground(url, formatJSON, "", entry)
+		}
+		return decodeCachedDocument(entry)
+	}
+	return fetchBatchDocument(ctx, url)
+}
@@ -0,0 +1,193 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"prosamik-backend/internal/fetcher"
+	"prosamik-backend/internal/imageproxy"
+	"prosamik-backend/internal/middleware"
+	"prosamik-backend/internal/policy"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// defaultImageCacheMB caps the disk image cache when IMAGE_CACHE_MAX_MB is not set
+const defaultImageCacheMB = 512
+
+var (
+	imageCache     *imageproxy.DiskCache
+	imageCacheOnce sync.Once
+)
+
+// getImageCache returns the disk image cache configured through IMAGE_CACHE_DIR and
+// IMAGE_CACHE_MAX_MB, or nil when it cannot be created and images are served uncached
+func getImageCache() *imageproxy.DiskCache {
+	imageCacheOnce.Do(func() {
+		dir := os.Getenv("IMAGE_CACHE_DIR")
+		if dir == "" {
+			dir = filepath.Join(os.TempDir(), "prosamik-images")
+		}
+
+		maxMB := defaultImageCacheMB
+		if value := os.Getenv("IMAGE_CACHE_MAX_MB"); value != "" {
+			if parsed, err := strconv.Atoi(value); err == nil && parsed > 0 {
+				maxMB = parsed
+			} else {
+				fmt.Printf("Warning: invalid IMAGE_CACHE_MAX_MB %q, using %d\n", value, defaultImageCacheMB)
+			}
+		}
+
+		c, err := imageproxy.NewDiskCache(dir, int64(maxMB)<<20)
+		if err != nil {
+			fmt.Printf("Warning: image cache disabled: %v\n", err)
+			return
+		}
+		imageCache = c
+	})
+	return imageCache
+}
+
+// ImageProxyHandler serves images from content sources through /img?url=<raw file URL>&w=<width>.
+// Only raw file URLs of the configured sources are proxied, and only for repositories the
+// document policy allows, since they are fetched with the sources' credentials.
+func ImageProxyHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	rawURL := r.URL.Query().Get("url")
+	if rawURL == "" {
+		http.Error(w, "URL parameter is required", http.StatusBadRequest)
+		return
+	}
+
+	width := 0
+	if value := r.URL.Query().Get("w"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 1 || parsed > imageproxy.MaxWidth {
+			http.Error(w, fmt.Sprintf("w must be between 1 and %d", imageproxy.MaxWidth), http.StatusBadRequest)
+			return
+		}
+		width = parsed
+	}
+
+	// The policy is checked before the disk cache so cached images of refused repositories stay hidden
+	source, ref, err := fetcher.ResolveRawFile(rawURL)
+	if err != nil {
+		http.Error(w, "URL is not allowed", http.StatusForbidden)
+		return
+	}
+	if !allowedByPolicy(w, ref, policy.CheckRawFile(r.Context(), source, ref)) {
+		return
+	}
+
+	diskCache := getImageCache()
+	variantKey := imageproxy.Key(rawURL, width)
+	if diskCache != nil {
+		if img, ok := diskCache.Get(variantKey); ok {
+			serveImage(w, r, img)
+			return
+		}
+	}
+
+	// Only images that have to be fetched or resized count against the limits
+	if !middleware.ImageLimiter.Charge(w, r, 1) {
+		return
+	}
+
+	original, err := loadOriginalImage(r, diskCache, source, rawURL)
+	if err != nil {
+		switch {
+		case errors.Is(err, errNotImage):
+			http.Error(w, "URL is not an image", http.StatusUnsupportedMediaType)
+		default:
+			fmt.Printf("Error fetching image %s: %v\n", rawURL, err)
+			http.Error(w, "Failed to fetch image", http.StatusBadGateway)
+		}
+		return
+	}
+
+	if width == 0 {
+		serveImage(w, r, original)
+		return
+	}
+
+	resized, err := imageproxy.Resize(original, width)
+	if err != nil {
+		fmt.Printf("Warning: failed to resize image %s: %v\n", rawURL, err)
+		serveImage(w, r, original)
+		return
+	}
+	if diskCache != nil {
+		if err := diskCache.Put(variantKey, resized); err != nil {
+			fmt.Printf("Warning: failed to cache image: %v\n", err)
+		}
+	}
+
+	serveImage(w, r, resized)
+}
+
+// errNotImage is returned when a proxied file is not an image
+var errNotImage = errors.New("file is not an image")
+
+// loadOriginalImage returns the full-size image from the disk cache or the content source
+func loadOriginalImage(r *http.Request, diskCache *imageproxy.DiskCache, source fetcher.ContentSource,
+	rawURL string) (*imageproxy.Image, error) {
+	key := imageproxy.Key(rawURL, 0)
+	if diskCache != nil {
+		if img, ok := diskCache.Get(key); ok {
+			return img, nil
+		}
+	}
+
+	file, err := fetcher.FetchRawFile(r.Context(), source, rawURL)
+	if err != nil {
+		return nil, err
+	}
+
+	img := &imageproxy.Image{
+		Body:        file.Body,
+		ContentType: imageproxy.DetectContentType(file.Body, file.ContentType),
+	}
+	if !strings.HasPrefix(img.ContentType, "image/") {
+		return nil, errNotImage
+	}
+
+	if diskCache != nil {
+		if err := diskCache.Put(key, img); err != nil {
+			fmt.Printf("Warning: failed to cache image: %v\n", err)
+		}
+	}
+	return img, nil
+}
+
+// serveImage writes an image with long-lived caching headers. The content security
+// policy keeps scripts inside SVG files from running when they are opened directly.
+func serveImage(w http.ResponseWriter, r *http.Request, img *imageproxy.Image) {
+	w.Header().Set("Content-Type", img.ContentType)
+	w.Header().Set("Content-Length", strconv.Itoa(len(img.Body)))
+	w.Header().Set("Cache-Control", "public, max-age=86400")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.Header().Set("Content-Security-Policy", "default-src 'none'; style-src 'unsafe-inline'; sandbox")
+
+	if r.Method == http.MethodHead {
+		return
+	}
+	if _, err := w.Write(img.Body); err != nil {
+		fmt.Printf("Warning: failed to write image: %v\n", err)
+	}
+}
+
+// proxiedImageURL routes a raw image URL through the image proxy at proxyBaseURL
+func proxiedImageURL(rawURL, proxyBaseURL string) string {
+	if rawURL == "" || proxyBaseURL == "" {
+		return rawURL
+	}
+	return proxyBaseURL + "?url=" + url.QueryEscape(rawURL)
+}
@@ -0,0 +1,192 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"prosamik-backend/internal/cache"
+	"prosamik-backend/internal/fetcher"
+	"prosamik-backend/internal/middleware"
+	"prosamik-backend/internal/policy"
+	"prosamik-backend/pkg/models"
+	"strings"
+	"sync"
+)
+
+const (
+	maxBatchURLs      = 50       // Documents accepted by a single /md/batch request
+	maxBatchBodyBytes = 64 << 10 // Size limit of the /md/batch request body
+	batchConcurrency  = 4        // Cache misses fetched at the same time per request
+)
+
+// BatchRequest is the body of POST /md/batch
+type BatchRequest struct {
+	URLs         []string `json:"urls"`
+	MetadataOnly bool     `json:"metadataOnly,omitempty"` // Omit the HTML content and toc, for list views
+}
+
+// BatchResult is the outcome for one URL of a batch; exactly one of Document and Error is set
+type BatchResult struct {
+	Document *models.MarkdownDocument `json:"document,omitempty"`
+	Error    string                   `json:"error,omitempty"`
+}
+
+// BatchResponse maps every requested URL to its result
+type BatchResponse struct {
+	Results map[string]BatchResult `json:"results"`
+}
+
+// HandleMarkdownBatch returns the /md JSON documents of several URLs at once.
+// Cached documents are served directly and misses are fetched concurrently.
+func HandleMarkdownBatch(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxBatchBodyBytes)
+
+	var req BatchRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	urls := uniqueURLs(req.URLs)
+	if len(urls) == 0 {
+		http.Error(w, "urls is required", http.StatusBadRequest)
+		return
+	}
+	if len(urls) > maxBatchURLs {
+		http.Error(w, fmt.Sprintf("At most %d urls are accepted per request", maxBatchURLs), http.StatusBadRequest)
+		return
+	}
+	// Every document counts against the limits; the middleware already counted the request itself
+	if !middleware.DocumentLimiter.Charge(w, r, len(urls)-1) {
+		return
+	}
+
+	response := BatchResponse{Results: make(map[string]BatchResult, len(urls))}
+	var mu sync.Mutex
+	record := func(url string, doc *models.MarkdownDocument, err error) {
+		result := BatchResult{Document: doc}
+		if err != nil {
+			result = BatchResult{Error: batchErrorMessage(err)}
+		} else if req.MetadataOnly {
+			doc.Content = ""
+			doc.TOC = nil
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+		response.Results[url] = result
+	}
+
+	// Serve cached documents first and collect the misses
+	var misses []string
+	for _, url := range urls {
+		if err := checkBatchPolicy(r.Context(), url); err != nil {
+			record(url, nil, err)
+			continue
+		}
+
+		cached, err := cache.GetCachedContent(r.Context(), documentCacheKey(url, formatJSON))
+		if err != nil || cached == nil {
+			misses = append(misses, url)
+			continue
+		}
+		if cached.IsStale() {
+			revalidateInBackground(url, formatJSON, "", cached)
+		}
+		doc, err := decodeCachedDocument(cached)
+		record(url, doc, err)
+	}
+
+	sem := make(chan struct{}, batchConcurrency)
+	var wg sync.WaitGroup
+	for _, url := range misses {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(url string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			doc, err := fetchBatchDocument(r.Context(), url)
+			record(url, doc, err)
+		}(url)
+	}
+	wg.Wait()
+
+	if r.Context().Err() != nil {
+		// The client went away; loads already started still fill the cache
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+	}
+}
+
+// checkBatchPolicy applies the document policy to a batch URL
+func checkBatchPolicy(ctx context.Context, url string) error {
+	source, ref, err := fetcher.ResolveSource(url)
+	if err != nil {
+		return fmt.Errorf("resolving content source: %w", err)
+	}
+	return policy.CheckDocument(ctx, source, ref)
+}
+
+// fetchBatchDocument loads a document missing from the cache, falling back to its last known good copy
+func fetchBatchDocument(ctx context.Context, url string) (*models.MarkdownDocument, error) {
+	source, ref, err := fetcher.ResolveSource(url)
+	if err != nil {
+		return nil, fmt.Errorf("resolving content source: %w", err)
+	}
+
+	entry, err := loadDocumentShared(ctx, url, formatJSON, source, ref)
+	if err != nil {
+		if errors.Is(err, context.Canceled) {
+			return nil, err
+		}
+		if stale := lastKnownGood(ctx, url, formatJSON, err); stale != nil {
+			return decodeCachedDocument(stale)
+		}
+		fmt.Printf("Error loading document %s: %v\n", url, err)
+		return nil, err
+	}
+	return decodeCachedDocument(entry)
+}
+
+// decodeCachedDocument decodes a cached JSON document
+func decodeCachedDocument(entry *cache.CachedContent) (*models.MarkdownDocument, error) {
+	var doc models.MarkdownDocument
+	if err := json.Unmarshal([]byte(entry.Content), &doc); err != nil {
+		return nil, fmt.Errorf("decoding rendered document: %w", err)
+	}
+	return &doc, nil
+}
+
+// batchErrorMessage describes a failed batch item
+func batchErrorMessage(err error) string {
+	var rateLimitErr *fetcher.RateLimitError
+	if errors.As(err, &rateLimitErr) {
+		return fmt.Sprintf("GitHub API rate limit reached, retry in %s", rateLimitErr.RetryAfter())
+	}
+	return err.Error()
+}
+
+// uniqueURLs trims the URLs and drops empty and repeated ones, keeping their order
+func uniqueURLs(urls []string) []string {
+	seen := make(map[string]bool, len(urls))
+	var unique []string
+	for _, url := range urls {
+		url = strings.TrimSpace(url)
+		if url != "" && !seen[url] {
+			seen[url] = true
+			unique = append(unique, url)
+		}
+	}
+	return unique
+}
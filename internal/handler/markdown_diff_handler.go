@@ -0,0 +1,244 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"html"
+	"net/http"
+	"os"
+	"prosamik-backend/internal/cache"
+	"prosamik-backend/internal/fetcher"
+	"prosamik-backend/internal/parser"
+	"prosamik-backend/pkg/models"
+	"strings"
+	"time"
+)
+
+// diffIndexFormat marks document index entries holding a cached diff against a branch
+const diffIndexFormat = "diff"
+
+// diffLinePrefixes marks the lines of a line-level diff like a unified diff does
+var diffLinePrefixes = map[parser.DiffKind]string{
+	parser.DiffEqual:  " ",
+	parser.DiffInsert: "+",
+	parser.DiffDelete: "-",
+}
+
+// HandleMarkdownDiff returns the changes of a document between two refs, either block by block
+// with each block rendered to HTML or line by line. Diffs between two commit SHAs are cached like pinned
+// documents; diffs naming a tag or branch are cached like branch documents, since those refs move.
+func HandleMarkdownDiff(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	query := r.URL.Query()
+	url, from, to := query.Get("url"), query.Get("from"), query.Get("to")
+	if url == "" || from == "" || to == "" {
+		http.Error(w, "url, from and to parameters are required", http.StatusBadRequest)
+		return
+	}
+
+	granularity := query.Get("granularity")
+	if granularity == "" {
+		granularity = "block"
+	}
+	if granularity != "block" && granularity != "line" {
+		http.Error(w, "granularity must be block or line", http.StatusBadRequest)
+		return
+	}
+
+	source, ref, err := fetcher.ResolveSource(url)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Error resolving content source: %v", err), http.StatusBadRequest)
+		return
+	}
+	if !allowDocument(w, r, source, ref) {
+		return
+	}
+
+	key := fmt.Sprintf("md:%s:diff:%s:%s..%s:%s", documentCacheVersion, granularity, from, to, url)
+	if cached, err := cache.GetCachedContent(r.Context(), key); err == nil && cached != nil {
+		writeDiff(w, cached.Content)
+		return
+	}
+
+	fromRef, toRef := *ref, *ref
+	if !pinDiffRef(w, r, source, &fromRef, from) || !pinDiffRef(w, r, source, &toRef, to) {
+		return
+	}
+
+	oldMarkdown, err := pinnedMarkdown(r.Context(), url, source, &fromRef)
+	if err != nil {
+		writeDiffLoadError(w, r, url, err)
+		return
+	}
+	newMarkdown, err := pinnedMarkdown(r.Context(), url, source, &toRef)
+	if err != nil {
+		writeDiffLoadError(w, r, url, err)
+		return
+	}
+
+	diff := &models.DocumentDiff{URL: url, From: from, To: to, Granularity: granularity}
+	if err := buildDiff(diff, oldMarkdown, newMarkdown, source, &fromRef, &toRef); err != nil {
+		http.Error(w, fmt.Sprintf("Error rendering diff: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	data, err := json.Marshal(diff)
+	if err != nil {
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		return
+	}
+	storeDiff(r.Context(), key, url, ref, from, to, string(data))
+
+	writeDiff(w, string(data))
+}
+
+// storeDiff caches a rendered diff. Diffs naming a branch are indexed under it for webhook invalidation.
+func storeDiff(ctx context.Context, key, url string, ref *fetcher.DocumentRef, from, to, data string) {
+	entry := &cache.CachedContent{Content: data, LastUpdated: time.Now()}
+	if fetcher.IsCommitSHA(from) && fetcher.IsCommitSHA(to) {
+		entry.Pinned = true
+		if err := cache.SetCachedDocument(ctx, key, entry); err != nil {
+			fmt.Printf("Warning: failed to cache diff: %v\n", err)
+		}
+		return
+	}
+
+	if err := cache.SetCachedContent(ctx, key, entry); err != nil {
+		fmt.Printf("Warning: failed to cache diff: %v\n", err)
+		return
+	}
+	names := []string{from}
+	if to != from {
+		names = append(names, to)
+	}
+	for _, name := range names {
+		if fetcher.IsCommitSHA(name) {
+			continue
+		}
+		err := cache.IndexDocument(ctx, ref.RepositoryKey(), cache.DocumentIndexEntry{
+			Key:    key,
+			URL:    url,
+			Format: diffIndexFormat,
+			Branch: name,
+			Path:   ref.Path,
+		})
+		if err != nil {
+			fmt.Printf("Warning: failed to index cached diff: %v\n", err)
+		}
+	}
+}
+
+// pinDiffRef pins one side of a diff to the commit its ref resolves to and reports whether it could
+func pinDiffRef(w http.ResponseWriter, r *http.Request, source fetcher.ContentSource, ref *fetcher.DocumentRef,
+	pinned string) bool {
+	err := fetcher.PinRef(r.Context(), source, ref, pinned)
+	if err == nil {
+		return true
+	}
+	if !writeRateLimited(w, err) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	}
+	return false
+}
+
+// writeDiffLoadError answers a diff whose document could not be loaded at one of the refs
+func writeDiffLoadError(w http.ResponseWriter, r *http.Request, url string, err error) {
+	if r.Context().Err() != nil || writeRateLimited(w, err) {
+		return
+	}
+	fmt.Printf("Error loading %s for diff: %v\n", url, err)
+	http.Error(w, fmt.Sprintf("Error loading document: %v", err), http.StatusBadGateway)
+}
+
+// pinnedMarkdown returns the Markdown of a document at a pinned ref without its front matter.
+// The raw Markdown is cached like a ?ref= request with format=markdown.
+func pinnedMarkdown(ctx context.Context, url string, source fetcher.ContentSource,
+	ref *fetcher.DocumentRef) (string, error) {
+	entry, err := cache.GetCachedContent(ctx, refCacheKey(url, formatMarkdown, ref))
+	if err != nil || entry == nil {
+		if entry, err = loadDocumentShared(ctx, url, formatMarkdown, source, ref); err != nil {
+			return "", fmt.Errorf("loading %s: %w", ref.Branch, err)
+		}
+	}
+
+	_, body, err := parser.ExtractFrontMatter(entry.Content)
+	if err != nil {
+		return entry.Content, nil
+	}
+	return body, nil
+}
+
+// buildDiff diffs two versions of a document and renders the result into diff
+func buildDiff(diff *models.DocumentDiff, oldMarkdown, newMarkdown string, source fetcher.ContentSource,
+	fromRef, toRef *fetcher.DocumentRef) error {
+	var ops []parser.DiffOp
+	if diff.Granularity == "line" {
+		ops = parser.Diff(parser.MarkdownLines(oldMarkdown), parser.MarkdownLines(newMarkdown))
+	} else {
+		ops = parser.Diff(parser.MarkdownBlocks(oldMarkdown), parser.MarkdownBlocks(newMarkdown))
+	}
+
+	var content strings.Builder
+	if diff.Granularity == "line" {
+		content.WriteString("<pre class=\"markdown-diff\">")
+	}
+	diff.Changes = make([]models.DiffChange, 0, len(ops))
+	for _, op := range ops {
+		diff.Changes = append(diff.Changes, models.DiffChange{Op: string(op.Kind), Text: op.Text})
+		switch op.Kind {
+		case parser.DiffInsert:
+			diff.Stats.Inserted++
+		case parser.DiffDelete:
+			diff.Stats.Deleted++
+		default:
+			diff.Stats.Unchanged++
+		}
+
+		if diff.Granularity == "line" {
+			fmt.Fprintf(&content, "<span class=\"markdown-diff-line markdown-diff-%s\">%s%s</span>\n",
+				op.Kind, diffLinePrefixes[op.Kind], html.EscapeString(op.Text))
+			continue
+		}
+
+		// Deleted blocks resolve images and links at the old ref, the others at the new one
+		ref := toRef
+		if op.Kind == parser.DiffDelete {
+			ref = fromRef
+		}
+		rendered, err := renderFragment(op.Text, source, ref)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(&content, "<div class=\"markdown-diff-block markdown-diff-%s\">\n%s</div>\n", op.Kind, rendered)
+	}
+	if diff.Granularity == "line" {
+		content.WriteString("</pre>\n")
+	}
+	diff.Content = content.String()
+	return nil
+}
+
+// renderFragment renders a piece of a document's Markdown, resolving images and links against its ref
+func renderFragment(markdown string, source fetcher.ContentSource, ref *fetcher.DocumentRef) (string, error) {
+	options, rawFileURL := renderOptions(source, ref)
+	processed := processImageURLs(markdown, ref.Path, rawFileURL, os.Getenv("IMAGE_PROXY_BASE_URL"))
+
+	rendered, err := parser.RenderMarkdown(processed, options)
+	if err != nil {
+		return "", fmt.Errorf("converting Markdown to HTML: %w", err)
+	}
+	return rendered.HTML, nil
+}
+
+// writeDiff writes an encoded DocumentDiff
+func writeDiff(w http.ResponseWriter, data string) {
+	w.Header().Set("Content-Type", "application/json")
+	if _, err := w.Write([]byte(data)); err != nil {
+		fmt.Printf("Warning: failed to write response: %v\n", err)
+	}
+}
@@ -0,0 +1,199 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"mime"
+	"net/http"
+	"prosamik-backend/internal/fetcher"
+	"prosamik-backend/internal/parser"
+	"prosamik-backend/pkg/models"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// documentFormat is a representation /md can return a document in
+type documentFormat string
+
+const (
+	formatJSON     documentFormat = "json"     // MarkdownDocument with rendered HTML
+	formatHTML     documentFormat = "html"     // Standalone lightweight HTML page
+	formatMarkdown documentFormat = "markdown" // Raw Markdown as fetched from the source
+	formatText     documentFormat = "text"     // Plain text for summarizers and email digests
+)
+
+// documentCacheVersion is part of every document cache key.
+// Bump it when the cached representation changes so that old entries are ignored.
+const documentCacheVersion = "v1"
+
+// formatContentTypes maps each format to the content type it is served with
+var formatContentTypes = map[documentFormat]string{
+	formatJSON:     "application/json",
+	formatHTML:     "text/html; charset=utf-8",
+	formatMarkdown: "text/markdown; charset=utf-8",
+	formatText:     "text/plain; charset=utf-8",
+}
+
+// acceptedMediaTypes maps Accept header media types to formats
+var acceptedMediaTypes = map[string]documentFormat{
+	"application/json": formatJSON,
+	"text/html":        formatHTML,
+	"text/markdown":    formatMarkdown,
+	"text/x-markdown":  formatMarkdown,
+	"text/plain":       formatText,
+}
+
+// documentCacheKey returns the cache key of a document in the given format
+func documentCacheKey(url string, format documentFormat) string {
+	return fmt.Sprintf("md:%s:%s:%s", documentCacheVersion, format, url)
+}
+
+// pinnedCacheKey returns the cache key of a document at a commit, kept apart from the
+// branch head entries so that a pinned copy never stands in for them
+func pinnedCacheKey(url string, format documentFormat, sha string) string {
+	return fmt.Sprintf("md:%s:pin:%s:%s:%s", documentCacheVersion, sha, format, url)
+}
+
+// refCacheKey returns the cache key of a document at the ref it was requested at
+func refCacheKey(url string, format documentFormat, ref *fetcher.DocumentRef) string {
+	if ref.Pinned {
+		return pinnedCacheKey(url, format, ref.Branch)
+	}
+	return documentCacheKey(url, format)
+}
+
+// negotiateFormat picks the response format from the format query parameter,
+// falling back to the Accept header and then to JSON
+func negotiateFormat(r *http.Request) (documentFormat, error) {
+	if value := r.URL.Query().Get("format"); value != "" {
+		format := documentFormat(strings.ToLower(value))
+		if _, ok := formatContentTypes[format]; !ok {
+			return "", fmt.Errorf("format must be one of json, html, markdown or text")
+		}
+		return format, nil
+	}
+
+	// Pick the acceptable media type with the highest quality, keeping header order on ties
+	type candidate struct {
+		format  documentFormat
+		quality float64
+	}
+	var candidates []candidate
+	for _, part := range strings.Split(r.Header.Get("Accept"), ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		format, ok := acceptedMediaTypes[mediaType]
+		if !ok {
+			continue
+		}
+		quality := 1.0
+		if q, err := strconv.ParseFloat(params["q"], 64); err == nil {
+			quality = q
+		}
+		if quality > 0 {
+			candidates = append(candidates, candidate{format: format, quality: quality})
+		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].quality > candidates[j].quality
+	})
+
+	if len(candidates) > 0 {
+		return candidates[0].format, nil
+	}
+	return formatJSON, nil
+}
+
+// encodeDocument builds the cached representation of a document in the given format
+func encodeDocument(format documentFormat, doc *models.MarkdownDocument, rendered *parser.Document, url string) (string, error) {
+	switch format {
+	case formatMarkdown:
+		return doc.RawContent, nil
+	case formatText:
+		return rendered.Text, nil
+	case formatHTML:
+		return renderDocumentPage(doc, url)
+	default:
+		// The raw Markdown has its own format, so JSON responses stay lean
+		jsonDoc := *doc
+		jsonDoc.RawContent = ""
+		data, err := json.Marshal(jsonDoc)
+		if err != nil {
+			return "", fmt.Errorf("marshaling response: %w", err)
+		}
+		return string(data), nil
+	}
+}
+
+// writeDocument writes a cached document representation. JSON responses have their outline
+// trimmed and the requested history, if any, added.
+func writeDocument(w http.ResponseWriter, format documentFormat, content string, toc tocOptions,
+	history *documentHistory) {
+	w.Header().Set("Content-Type", formatContentTypes[format])
+	w.Header().Add("Vary", "Accept")
+
+	if format != formatJSON {
+		if _, err := w.Write([]byte(content)); err != nil {
+			fmt.Printf("Warning: failed to write response: %v\n", err)
+		}
+		return
+	}
+
+	var response models.MarkdownDocument
+	if err := json.Unmarshal([]byte(content), &response); err != nil {
+		http.Error(w, "Failed to decode rendered document", http.StatusInternalServerError)
+		return
+	}
+	toc.apply(&response)
+	history.apply(&response)
+
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+	}
+}
+
+var (
+	highlightCSS     template.CSS
+	highlightCSSOnce sync.Once
+)
+
+// documentPageData is passed to the document page template
+type documentPageData struct {
+	Document     *models.MarkdownDocument
+	Canonical    string
+	HighlightCSS template.CSS
+}
+
+// renderDocumentPage renders a document as a standalone page with inline styles and no scripts
+func renderDocumentPage(doc *models.MarkdownDocument, url string) (string, error) {
+	highlightCSSOnce.Do(func() {
+		var buf bytes.Buffer
+		if err := parser.WriteHighlightCSS(&buf); err != nil {
+			fmt.Printf("Warning: failed to build highlight stylesheet: %v\n", err)
+			return
+		}
+		highlightCSS = template.CSS(buf.String())
+	})
+
+	canonical := doc.Metadata.CanonicalURL
+	if canonical == "" {
+		canonical = url
+	}
+
+	var buf bytes.Buffer
+	err := templates.ExecuteTemplate(&buf, "document", documentPageData{
+		Document:     doc,
+		Canonical:    canonical,
+		HighlightCSS: highlightCSS,
+	})
+	if err != nil {
+		return "", fmt.Errorf("rendering document page: %w", err)
+	}
+	return buf.String(), nil
+}
@@ -1,77 +1,31 @@
 package handler
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"golang.org/x/sync/singleflight"
+	"io/fs"
 	"net/http"
+	"os"
 	"path/filepath"
 	"prosamik-backend/internal/cache"
 	"prosamik-backend/internal/fetcher"
 	"prosamik-backend/internal/parser"
+	"prosamik-backend/internal/policy"
 	"prosamik-backend/pkg/models"
 	"regexp"
+	"strconv"
 	"strings"
+	"sync"
+	"time"
 )
 
-// GitHubCommit represents a single commit in GitHub's API response
-type GitHubCommit struct {
-	Sha    string `json:"sha"`
-	Commit struct {
-		Author struct {
-			Name  string `json:"name"`
-			Email string `json:"email"`
-			Date  string `json:"date"`
-		} `json:"author"`
-		Committer struct {
-			Name  string `json:"name"`
-			Email string `json:"email"`
-			Date  string `json:"date"`
-		} `json:"committer"`
-		Message string `json:"message"`
-	} `json:"commit"`
-}
-
-// constructGitHubAPIURL parses a GitHub URL and returns API URL and repository details
-func constructGitHubAPIURL(githubURL string) (string, string, string, string, string, error) {
-	repoPrefix := "https://github.com/"
-	if !strings.HasPrefix(githubURL, repoPrefix) {
-		return "", "", "", "", "", fmt.Errorf("URL must start with %s", repoPrefix)
-	}
-
-	repoPath := strings.TrimPrefix(githubURL, repoPrefix)
-	parts := strings.Split(repoPath, "/")
-
-	if len(parts) < 2 {
-		return "", "", "", "", "", fmt.Errorf("invalid GitHub URL format: %s", githubURL)
-	}
-
-	owner := parts[0]
-	repo := parts[1]
-	var filePath string
-	branchName := "main" // default branch
-
-	if strings.Contains(githubURL, "/blob/") {
-		branchName = parts[3]
-		filePath = strings.Join(parts[4:], "/")
-		apiURL := fmt.Sprintf("https://api.github.com/repos/%s/%s/contents/%s?ref=%s",
-			owner, repo, filePath, branchName)
-		return apiURL, owner, repo, filePath, branchName, nil
-	} else if strings.Contains(githubURL, "/tree/") {
-		branchName = parts[3]
-		filePath = strings.Join(parts[4:], "/") + "/README.md"
-		apiURL := fmt.Sprintf("https://api.github.com/repos/%s/%s/contents/%s?ref=%s",
-			owner, repo, filePath, branchName)
-		return apiURL, owner, repo, filePath, branchName, nil
-	} else {
-		filePath = "README.md"
-		apiURL := fmt.Sprintf("https://api.github.com/repos/%s/%s/contents/README.md",
-			owner, repo)
-		return apiURL, owner, repo, filePath, branchName, nil
-	}
-}
-
-// processImageURLs converts relative image URLs to raw.githubusercontent.com URLs
-func processImageURLs(content, owner, repo, branch, markdownPath string) string {
+// processImageURLs converts relative image URLs to raw file URLs of the content source,
+// routed through the image proxy at proxyBaseURL when it is not empty.
+// Images are left untouched when rawFileURL returns an empty string.
+func processImageURLs(content, markdownPath string, rawFileURL func(filePath string) string, proxyBaseURL string) string {
 	markdownDir := filepath.Dir(markdownPath)
 
 	// Handle Markdown image syntax ![alt](path)
@@ -86,8 +40,9 @@ func processImageURLs(content, owner, repo, branch, markdownPath string) string
 		altText := parts[1]
 		imagePath := parts[2]
 
-		// Skip URLs that somehow matched our pattern
-		if strings.HasPrefix(imagePath, "http://") || strings.HasPrefix(imagePath, "https://") {
+		// Skip URLs that somehow matched our pattern and embedded images
+		if strings.HasPrefix(imagePath, "http://") || strings.HasPrefix(imagePath, "https://") ||
+			strings.HasPrefix(imagePath, "data:") {
 			return match
 		}
 
@@ -113,8 +68,10 @@ func processImageURLs(content, owner, repo, branch, markdownPath string) string
 			}
 		}
 
-		rawURL := fmt.Sprintf("https://raw.githubusercontent.com/%s/%s/%s/%s",
-			owner, repo, branch, fullPath)
+		rawURL := proxiedImageURL(rawFileURL(fullPath), proxyBaseURL)
+		if rawURL == "" {
+			return match
+		}
 
 		return fmt.Sprintf("![%s](%s)", altText, rawURL)
 	})
@@ -129,8 +86,9 @@ func processImageURLs(content, owner, repo, branch, markdownPath string) string
 
 		imagePath := parts[1]
 
-		// Skip URLs that somehow matched our pattern
-		if strings.HasPrefix(imagePath, "http://") || strings.HasPrefix(imagePath, "https://") {
+		// Skip URLs that somehow matched our pattern and embedded images
+		if strings.HasPrefix(imagePath, "http://") || strings.HasPrefix(imagePath, "https://") ||
+			strings.HasPrefix(imagePath, "data:") {
 			return match
 		}
 
@@ -147,8 +105,10 @@ func processImageURLs(content, owner, repo, branch, markdownPath string) string
 
 		fullPath = filepath.ToSlash(fullPath)
 
-		rawURL := fmt.Sprintf("https://raw.githubusercontent.com/%s/%s/%s/%s",
-			owner, repo, branch, fullPath)
+		rawURL := proxiedImageURL(rawFileURL(fullPath), proxyBaseURL)
+		if rawURL == "" {
+			return match
+		}
 
 		return strings.Replace(match, parts[1], rawURL, 1)
 	})
@@ -156,6 +116,39 @@ func processImageURLs(content, owner, repo, branch, markdownPath string) string
 	return content
 }
 
+// resolveImageURL resolves an image path relative to the Markdown file the same way
+// processImageURLs does; absolute URLs are returned unchanged
+func resolveImageURL(imagePath, markdownPath string, rawFileURL func(filePath string) string, proxyBaseURL string) string {
+	if imagePath == "" || strings.HasPrefix(imagePath, "http://") || strings.HasPrefix(imagePath, "https://") ||
+		strings.HasPrefix(imagePath, "data:") {
+		return imagePath
+	}
+
+	fullPath := filepath.ToSlash(filepath.Join(filepath.Dir(markdownPath), strings.TrimPrefix(imagePath, "./")))
+	if resolved := proxiedImageURL(rawFileURL(fullPath), proxyBaseURL); resolved != "" {
+		return resolved
+	}
+	return imagePath
+}
+
+// applyFrontMatter overrides the derived document metadata with the fields set in front matter
+func applyFrontMatter(metadata *models.DocumentMetadata, fm *parser.FrontMatter, resolveImage func(string) string) {
+	if fm.Title != "" {
+		metadata.Title = fm.Title
+	}
+	if fm.Description != "" {
+		metadata.Description = fm.Description
+	}
+	if !fm.Date.IsZero() {
+		date := fm.Date
+		metadata.Date = &date
+	}
+	metadata.Tags = fm.Tags
+	metadata.CoverImage = resolveImage(fm.CoverImage)
+	metadata.CanonicalURL = fm.Canonical
+	metadata.Draft = fm.Draft
+}
+
 // getFileName gets filename of the Markdown file
 func getFileName(filePath string) string {
 	parts := strings.Split(filePath, "/")
@@ -171,7 +164,60 @@ func processImageURL(url string) string {
 	return strings.ReplaceAll(url, "/../", "/")
 }
 
-// MarkdownHandler processes GitHub markdown content and returns rendered HTML
+// revalidating tracks documents with a background revalidation in flight
+var revalidating sync.Map
+
+// documentLoads coalesces concurrent cache misses of the same document and format
+var documentLoads singleflight.Group
+
+// documentLoadTimeout bounds loads that run detached from a request
+const documentLoadTimeout = 30 * time.Second
+
+// defaultTOCDepth includes every heading level in the table of contents
+const defaultTOCDepth = 6
+
+// tocOptions controls the table of contents returned with a document
+type tocOptions struct {
+	include  bool // Whether the toc is returned at all
+	maxDepth int  // Deepest heading level included
+}
+
+// parseTOCOptions reads the toc and tocDepth query parameters
+func parseTOCOptions(r *http.Request) (tocOptions, error) {
+	opts := tocOptions{include: true, maxDepth: defaultTOCDepth}
+
+	if toc := r.URL.Query().Get("toc"); toc != "" {
+		include, err := strconv.ParseBool(toc)
+		if err != nil {
+			return opts, fmt.Errorf("toc must be true or false")
+		}
+		opts.include = include
+	}
+
+	if depth := r.URL.Query().Get("tocDepth"); depth != "" {
+		maxDepth, err := strconv.Atoi(depth)
+		if err != nil || maxDepth < 1 || maxDepth > 6 {
+			return opts, fmt.Errorf("tocDepth must be a number between 1 and 6")
+		}
+		opts.maxDepth = maxDepth
+	}
+
+	return opts, nil
+}
+
+// apply trims the cached full outline of a document to the requested options
+func (o tocOptions) apply(doc *models.MarkdownDocument) {
+	if !o.include {
+		doc.TOC = nil
+		return
+	}
+	if o.maxDepth < defaultTOCDepth {
+		doc.TOC = parser.LimitTOCDepth(doc.TOC, o.maxDepth)
+	}
+}
+
+// MarkdownHandler processes markdown content from any supported content source and returns it
+// as JSON with rendered HTML, a standalone HTML page, raw Markdown or plain text
 func MarkdownHandler(w http.ResponseWriter, r *http.Request) {
 	url := r.URL.Query().Get("url")
 	if url == "" {
@@ -179,115 +225,407 @@ func MarkdownHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Try to get from cache first
-	cached, err := cache.GetCachedContent(r.Context(), url)
-	if err == nil && cached != nil {
-		// Unmarshal the cached response
-		var response models.MarkdownDocument
-		if err := json.Unmarshal([]byte(cached.Content), &response); err != nil {
-			fmt.Printf("Warning: failed to unmarshal cached response: %v\n", err)
-			// Continue with normal processing since cache read failed
-		} else {
-			w.Header().Set("Content-Type", "application/json")
-			if err := json.NewEncoder(w).Encode(response); err != nil {
-				http.Error(w, "Failed to encode cached response", http.StatusInternalServerError)
+	format, err := negotiateFormat(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	toc, err := parseTOCOptions(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	historyLimit, err := parseHistory(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if historyLimit > 0 && format != formatJSON {
+		http.Error(w, "history is only available in the JSON format", http.StatusBadRequest)
+		return
+	}
+
+	source, ref, err := fetcher.ResolveSource(url)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Error resolving content source: %v", err), http.StatusBadRequest)
+		return
+	}
+	if !allowDocument(w, r, source, ref) {
+		return
+	}
+
+	// Documents at a ref are pinned to the commit it resolves to and cached under that commit
+	if pinned := r.URL.Query().Get("ref"); pinned != "" {
+		if err := fetcher.PinRef(r.Context(), source, ref, pinned); err != nil {
+			if writeRateLimited(w, err) {
 				return
 			}
+			http.Error(w, err.Error(), http.StatusBadRequest)
 			return
 		}
 	}
 
+	// Try to get from cache first
+	cached, err := cache.GetCachedContent(r.Context(), refCacheKey(url, format, ref))
+	if err == nil && cached != nil {
+		// Serve stale content immediately and refresh it in the background
+		if cached.IsStale() {
+			revalidateInBackground(url, format, pinnedCommit(ref), cached)
+		}
+
+		writeDocument(w, format, cached.Content, toc, requestedHistory(r.Context(), url, source, ref, historyLimit))
+		return
+	}
+
 	// If not in cache or error, proceed with normal processing
-	apiURL, owner, repo, filePath, branch, err := constructGitHubAPIURL(url)
+	entry, err := loadDocumentShared(r.Context(), url, format, source, ref)
 	if err != nil {
-		http.Error(w, fmt.Sprintf("Error constructing GitHub API URL: %v", err),
-			http.StatusBadRequest)
+		if r.Context().Err() != nil {
+			// The client went away; the shared load still completes for the other waiters
+			return
+		}
+		if stale := lastKnownGood(r.Context(), url, format, err); stale != nil && !ref.Pinned {
+			w.Header().Set("Warning", staleWarning)
+			writeDocument(w, format, stale.Content, toc, requestedHistory(r.Context(), url, source, ref, historyLimit))
+			return
+		}
+		if writeRateLimited(w, err) {
+			return
+		}
+		fmt.Printf("Error loading document %s: %v\n", url, err)
+		http.Error(w, fmt.Sprintf("Error loading document: %v", err), http.StatusInternalServerError)
 		return
 	}
 
-	markdownContent, err := fetcher.FetchContentFromGitHubURL(r.Context(), apiURL)
-	if err != nil {
-		http.Error(w, fmt.Sprintf("Error fetching content: %v", err),
-			http.StatusInternalServerError)
+	writeDocument(w, format, entry.Content, toc, requestedHistory(r.Context(), url, source, ref, historyLimit))
+}
+
+// pinnedCommit returns the commit a pinned document is at, or an empty string for branch heads
+func pinnedCommit(ref *fetcher.DocumentRef) string {
+	if ref.Pinned {
+		return ref.Branch
+	}
+	return ""
+}
+
+// writeRateLimited answers with 503 and Retry-After when err comes from an exhausted
+// GitHub API rate limit, and reports whether it did
+func writeRateLimited(w http.ResponseWriter, err error) bool {
+	var rateLimitErr *fetcher.RateLimitError
+	if !errors.As(err, &rateLimitErr) {
+		return false
+	}
+
+	w.Header().Set("Retry-After", strconv.Itoa(int(rateLimitErr.RetryAfter().Seconds())))
+	http.Error(w, "GitHub API rate limit reached, try again later", http.StatusServiceUnavailable)
+	return true
+}
+
+// staleWarning is the Warning header of documents served from their last known good copy
+const staleWarning = `111 - "Revalidation Failed"`
+
+// lastKnownGood returns the last good copy of a document whose load failed with loadErr, marked as
+// stale, or nil when there is none or the document no longer exists at its source
+func lastKnownGood(ctx context.Context, url string, format documentFormat, loadErr error) *cache.CachedContent {
+	if errors.Is(loadErr, fetcher.ErrNotFound) || errors.Is(loadErr, fs.ErrNotExist) {
+		return nil
+	}
+
+	entry, err := cache.GetLastKnownGood(ctx, documentCacheKey(url, format))
+	if err != nil || entry == nil {
+		return nil
+	}
+	if format == formatJSON {
+		doc, err := decodeCachedDocument(entry)
+		if err != nil {
+			return nil
+		}
+		doc.Stale = true
+		data, err := json.Marshal(doc)
+		if err != nil {
+			return nil
+		}
+		entry.Content = string(data)
+	}
+
+	fmt.Printf("Warning: serving the last known good copy of %s: %v\n", url, loadErr)
+	return entry
+}
+
+// allowDocument applies the document policy and reports whether the request may go on.
+// Refused documents are answered with 403, missing repositories with 404, and policies that
+// cannot be evaluated with an error.
+func allowDocument(w http.ResponseWriter, r *http.Request, source fetcher.ContentSource, ref *fetcher.DocumentRef) bool {
+	return allowedByPolicy(w, ref, policy.CheckDocument(r.Context(), source, ref))
+}
+
+// allowedByPolicy answers a refused or failed policy check and reports whether the request may go on
+func allowedByPolicy(w http.ResponseWriter, ref *fetcher.DocumentRef, err error) bool {
+	switch {
+	case err == nil:
+		return true
+	case errors.Is(err, policy.ErrDenied):
+		http.Error(w, err.Error(), http.StatusForbidden)
+	case errors.Is(err, fetcher.ErrNotFound):
+		// The repository does not exist, which is the client's mistake rather than an upstream failure
+		http.Error(w, fmt.Sprintf("Repository %s/%s not found", ref.Owner, ref.Repo), http.StatusNotFound)
+	case writeRateLimited(w, err):
+	default:
+		fmt.Printf("Error checking document policy for %s/%s: %v\n", ref.Owner, ref.Repo, err)
+		http.Error(w, "Error checking document access", http.StatusBadGateway)
+	}
+	return false
+}
+
+// revalidateInBackground refreshes a stale cache entry without blocking the request. Entries
+// pinned to a commit pass its SHA, branch head entries an empty one.
+// Only one revalidation runs per document and format at a time.
+func revalidateInBackground(url string, format documentFormat, commit string, cached *cache.CachedContent) {
+	key := documentCacheKey(url, format)
+	if commit != "" {
+		key = pinnedCacheKey(url, format, commit)
+	}
+	if _, inFlight := revalidating.LoadOrStore(key, true); inFlight {
 		return
 	}
 
-	// Process image URLs before converting to HTML
-	processedContent := processImageURLs(markdownContent, owner, repo, branch, filePath)
-
-	// Construct commits API URL and fetch last updated time
-	commitsURL := fmt.Sprintf("https://api.github.com/repos/%s/%s/commits?path=%s&sha=%s&page=1&per_page=1",
-		owner, repo, filePath, branch)
-	lastUpdated, err := fetcher.FetchLastCommitData(r.Context(), commitsURL)
-	if err != nil && branch == "main" {
-		// If the main branch fails, try with "master" branch
-		masterCommitsURL := fmt.Sprintf("https://api.github.com/repos/%s/%s/commits?path=%s&sha=%s&page=1&per_page=1",
-			owner, repo, filePath, "master")
-		lastUpdated, err = fetcher.FetchLastCommitData(r.Context(), masterCommitsURL)
+	go func() {
+		defer revalidating.Delete(key)
+
+		ctx, cancel := context.WithTimeout(context.Background(), documentLoadTimeout)
+		defer cancel()
+
+		source, ref, err := fetcher.ResolveSource(url)
 		if err != nil {
-			http.Error(w, "Failed to fetch document metadata from both main and master branches", http.StatusInternalServerError)
+			fmt.Printf("Warning: failed to revalidate %s: %v\n", url, err)
 			return
 		}
-	} else if err != nil {
-		http.Error(w, "Failed to fetch document metadata", http.StatusInternalServerError)
-		return
+		if commit != "" {
+			ref.Branch, ref.Pinned, ref.RefKind = commit, true, "commit"
+		}
+
+		if _, err := loadDocument(ctx, url, format, source, ref, cached); err != nil {
+			fmt.Printf("Warning: failed to revalidate %s: %v\n", url, err)
+		}
+	}()
+}
+
+// loadDocumentShared loads a document that is not cached, sharing a single upstream fetch and
+// render between concurrent requests for the same document and format. The load is detached
+// from ctx so that a cancelled request does not fail the others; ctx only ends the wait.
+func loadDocumentShared(ctx context.Context, url string, format documentFormat, source fetcher.ContentSource,
+	ref *fetcher.DocumentRef) (*cache.CachedContent, error) {
+	results := documentLoads.DoChan(refCacheKey(url, format, ref), func() (interface{}, error) {
+		loadCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), documentLoadTimeout)
+		defer cancel()
+
+		return loadDocument(loadCtx, url, format, source, ref, nil)
+	})
+
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case result := <-results:
+		if result.Err != nil {
+			return nil, result.Err
+		}
+		return result.Val.(*cache.CachedContent), nil
+	}
+}
+
+// loadDocument fetches and renders a document and stores it in the cache in the requested format.
+// When a previous cache entry is given, its validators make the fetch conditional
+// and an unchanged document only has its freshness extended.
+func loadDocument(ctx context.Context, url string, format documentFormat, source fetcher.ContentSource,
+	ref *fetcher.DocumentRef, previous *cache.CachedContent) (*cache.CachedContent, error) {
+	// Resolve the default branch so content, metadata and images use the same ref
+	if err := fetcher.ResolveBranch(ctx, source, ref); err != nil {
+		return nil, fmt.Errorf("resolving default branch: %w", err)
+	}
+
+	var validators fetcher.Validators
+	if previous != nil {
+		validators = fetcher.Validators{ETag: previous.ETag, LastModified: previous.LastModified}
 	}
 
-	renderedHTML, err := parser.ConvertMarkdownToHTML(processedContent)
+	result, err := source.FetchContent(ctx, ref, validators)
 	if err != nil {
-		http.Error(w, "Failed to convert Markdown to HTML", http.StatusInternalServerError)
-		return
+		return nil, fmt.Errorf("fetching content: %w", err)
 	}
 
-	// Get the title based on URL type
-	title := repo // default title
-	if strings.Contains(url, "/blob/") || strings.Contains(url, "/tree/") {
-		title = getFileName(filePath)
+	if result.NotModified && previous != nil {
+		entry := *previous
+		entry.ETag = result.Validators.ETag
+		entry.LastModified = result.Validators.LastModified
+		entry.FetchedAt = time.Now()
+		storeDocument(ctx, url, format, ref, &entry)
+		return &entry, nil
 	}
 
-	// Get description from content if available
-	description := "This is the README for the repository." // default description
-	if len(markdownContent) > 0 {
-		// Take the first 200 characters, trim to last complete word
-		if len(markdownContent) > 100 {
-			description = markdownContent[:100]
-			lastSpace := strings.LastIndex(description, " ")
-			if lastSpace > 0 {
-				description = description[:lastSpace] + "..."
-			}
-		} else {
-			description = markdownContent
-		}
+	// Commit metadata is best-effort: without it the document is rendered as partial
+	lastUpdated, err := source.FetchLastUpdated(ctx, ref)
+	if err != nil {
+		fmt.Printf("Warning: leaving out the last update of %s: %v\n", url, err)
+		lastUpdated = time.Time{}
 	}
 
-	response := models.MarkdownDocument{
-		Content: renderedHTML,
-		//RawContent: markdownContent,
-		Metadata: models.DocumentMetadata{
-			Title:       title,
-			Repository:  repo,
-			LastUpdated: lastUpdated,
-			Author:      owner,
-			Description: description,
-		},
+	response, rendered, err := renderDocument(result.Content, source, ref, lastUpdated)
+	if err != nil {
+		return nil, err
 	}
 
-	// Cache the response before sending
-	responseBytes, err := json.Marshal(response)
+	content, err := encodeDocument(format, response, rendered, url)
 	if err != nil {
-		fmt.Printf("Warning: failed to marshal response for caching: %v\n", err)
+		return nil, err
+	}
+
+	entry := &cache.CachedContent{
+		Content:      content,
+		LastUpdated:  lastUpdated,
+		ETag:         result.Validators.ETag,
+		LastModified: result.Validators.LastModified,
+		FetchedAt:    time.Now(),
+		Pinned:       ref.Pinned,
+	}
+	if lastUpdated.IsZero() {
+		// Partial documents are revalidated soon and without validators, so that the
+		// metadata is fetched again even if the content has not changed
+		entry.Partial = true
+		entry.Pinned = false
+		entry.ETag, entry.LastModified = "", ""
+	}
+
+	// Store in cache
+	storeDocument(ctx, url, format, ref, entry)
+
+	return entry, nil
+}
+
+// storeDocument caches a document and indexes it under its repository for webhook invalidation.
+// Documents pinned to a commit never change, so they are not indexed and need no last known good copy.
+func storeDocument(ctx context.Context, url string, format documentFormat, ref *fetcher.DocumentRef,
+	entry *cache.CachedContent) {
+	key := refCacheKey(url, format, ref)
+	if err := cache.SetCachedDocument(ctx, key, entry); err != nil {
+		fmt.Printf("Warning: failed to cache response: %v\n", err)
+		return
+	}
+	if ref.Pinned {
+		return
+	}
+	if err := cache.SetLastKnownGood(ctx, key, entry); err != nil {
+		fmt.Printf("Warning: failed to keep last known good copy: %v\n", err)
+	}
+
+	err := cache.IndexDocument(ctx, ref.RepositoryKey(), cache.DocumentIndexEntry{
+		Key:    key,
+		URL:    url,
+		Format: string(format),
+		Branch: ref.Branch,
+		Path:   ref.Path,
+	})
+	if err != nil {
+		fmt.Printf("Warning: failed to index cached document: %v\n", err)
+	}
+}
+
+// renderDocument runs a document through the rendering pipeline and builds the /md response.
+// Notebooks and plain text files are converted to Markdown first, picked by the file extension,
+// and the converted Markdown is what ?format=markdown returns for them.
+// Images, links and references are resolved against the source and ref; without a source
+// they are left as written.
+func renderDocument(rawContent string, source fetcher.ContentSource, ref *fetcher.DocumentRef,
+	lastUpdated time.Time) (*models.MarkdownDocument, *parser.Document, error) {
+	var frontMatter *parser.FrontMatter
+	markdownContent := rawContent
+	sourceFormat := parser.DetectSourceFormat(ref.Path)
+	if sourceFormat == parser.SourceMarkdown {
+		// Front matter overrides the derived metadata and is never rendered
+		var err error
+		frontMatter, markdownContent, err = parser.ExtractFrontMatter(rawContent)
+		if err != nil {
+			fmt.Printf("Warning: ignoring front matter of %s: %v\n", ref.Path, err)
+		}
 	} else {
-		// Store in cache
-		if err := cache.SetCachedContent(r.Context(), url, &cache.CachedContent{
-			Content:     string(responseBytes),
-			LastUpdated: lastUpdated,
-		}); err != nil {
-			fmt.Printf("Warning: failed to cache response: %v\n", err)
+		converted, err := parser.ToMarkdown(sourceFormat, rawContent)
+		if err != nil {
+			return nil, nil, fmt.Errorf("converting %s to Markdown: %w", ref.Path, err)
 		}
+		markdownContent = converted
+		rawContent = converted
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	if err := json.NewEncoder(w).Encode(response); err != nil {
-		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+	// Process image URLs before converting to HTML
+	options, rawFileURL := renderOptions(source, ref)
+	proxyBaseURL := os.Getenv("IMAGE_PROXY_BASE_URL")
+	processedContent := processImageURLs(markdownContent, ref.Path, rawFileURL, proxyBaseURL)
+
+	rendered, err := parser.RenderMarkdown(processedContent, options)
+	if err != nil {
+		return nil, nil, fmt.Errorf("converting Markdown to HTML: %w", err)
+	}
+
+	// Get the title based on URL type
+	title := ref.Repo // default title
+	if ref.ExplicitPath {
+		title = getFileName(ref.Path)
+	}
+
+	// Describe the document by its first paragraph
+	description := "This is the README for the repository." // default description
+	if rendered.Summary.Excerpt != "" {
+		description = rendered.Summary.Excerpt
+	}
+
+	metadata := models.DocumentMetadata{
+		Title:       title,
+		Repository:  ref.Repo,
+		Author:      ref.Owner,
+		Description: description,
+
+		Excerpt:      rendered.Summary.Excerpt,
+		WordCount:    rendered.Summary.WordCount,
+		ReadingTime:  rendered.Summary.ReadingTime,
+		HeadingCount: rendered.Summary.HeadingCount,
+		Images:       rendered.Summary.Images,
+	}
+	if lastUpdated.IsZero() {
+		metadata.Partial = true
+	} else {
+		metadata.LastUpdated = &lastUpdated
+	}
+	if frontMatter != nil {
+		applyFrontMatter(&metadata, frontMatter, func(imagePath string) string {
+			return resolveImageURL(imagePath, ref.Path, rawFileURL, proxyBaseURL)
+		})
+	}
+
+	return &models.MarkdownDocument{
+		Content:    rendered.HTML,
+		RawContent: rawContent,
+		Metadata:   metadata,
+		TOC:        rendered.TOC,
+	}, rendered, nil
+}
+
+// renderOptions returns the options resolving links and references of a document's Markdown and the
+// function mapping repository files to raw file URLs. Without a source both leave content as written.
+func renderOptions(source fetcher.ContentSource, ref *fetcher.DocumentRef) (parser.Options, func(filePath string) string) {
+	rawFileURL := func(string) string { return "" }
+	options := parser.Options{Host: ref.Host, Owner: ref.Owner, Repo: ref.Repo}
+	if source != nil {
+		rawFileURL = func(filePath string) string {
+			return source.RawFileURL(ref, filePath)
+		}
+
+		// Relative links are rewritten to /md or the source, and sources that know how to
+		// link issues, users and commits get GitHub-style references
+		options.RewriteLink = documentLinkRewriter(source, ref)
+		if linker, ok := source.(parser.ReferenceLinker); ok {
+			options.Linker = linker
+		}
 	}
+	return options, rawFileURL
 }
@@ -0,0 +1,185 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"prosamik-backend/internal/cache"
+	"prosamik-backend/internal/fetcher"
+	"prosamik-backend/internal/parser"
+	"prosamik-backend/pkg/models"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// historyIndexFormat marks document index entries holding a cached history instead of a document
+const historyIndexFormat = "history"
+
+// documentHistory is the cached commit history of a document
+type documentHistory struct {
+	Commits      []models.CommitInfo  `json:"commits"`
+	Contributors []models.Contributor `json:"contributors"`
+
+	unavailable bool // Requested but failed to load, which makes the document partial
+}
+
+// parseHistory reads the history query parameter; 0 means no history
+func parseHistory(r *http.Request) (int, error) {
+	value := r.URL.Query().Get("history")
+	if value == "" {
+		return 0, nil
+	}
+
+	limit, err := strconv.Atoi(value)
+	if err != nil || limit < 0 || limit > fetcher.MaxHistory {
+		return 0, fmt.Errorf("history must be a number between 0 and %d", fetcher.MaxHistory)
+	}
+	return limit, nil
+}
+
+// historyCacheKey returns the cache key of the last limit commits of a document at the ref
+// it was requested at. Histories pinned to a commit are kept apart from the branch head ones.
+func historyCacheKey(url string, limit int, ref *fetcher.DocumentRef) string {
+	if ref.Pinned {
+		return fmt.Sprintf("md:%s:%s:pin:%s:%d:%s", documentCacheVersion, historyIndexFormat, ref.Branch, limit, url)
+	}
+	return fmt.Sprintf("md:%s:%s:%d:%s", documentCacheVersion, historyIndexFormat, limit, url)
+}
+
+// requestedHistory returns the history asked for with ?history=N, or nil when none was asked for
+// or the source has none. A history that fails to load never fails the document itself,
+// it only marks it as partial.
+func requestedHistory(ctx context.Context, url string, source fetcher.ContentSource, ref *fetcher.DocumentRef,
+	limit int) *documentHistory {
+	if limit == 0 {
+		return nil
+	}
+
+	history, err := loadHistory(ctx, url, source, ref, limit)
+	if err != nil {
+		if errors.Is(err, fetcher.ErrHistoryUnsupported) {
+			return nil
+		}
+		fmt.Printf("Warning: failed to load history of %s: %v\n", url, err)
+		return &documentHistory{unavailable: true}
+	}
+	return history
+}
+
+// loadHistory returns the last limit commits touching a document and their authors, up to the
+// pinned commit for documents requested with ?ref=. Histories are cached for cache.TTL and
+// invalidated by push webhooks like documents; pinned histories never change and are kept like pinned documents.
+func loadHistory(ctx context.Context, url string, source fetcher.ContentSource, documentRef *fetcher.DocumentRef,
+	limit int) (*documentHistory, error) {
+	key := historyCacheKey(url, limit, documentRef)
+	if cached, err := cache.GetCachedContent(ctx, key); err == nil && cached != nil {
+		var history documentHistory
+		if err := json.Unmarshal([]byte(cached.Content), &history); err == nil {
+			return &history, nil
+		}
+	}
+
+	ref := *documentRef
+	if err := fetcher.ResolveBranch(ctx, source, &ref); err != nil {
+		return nil, fmt.Errorf("resolving default branch: %w", err)
+	}
+
+	commits, err := fetcher.FetchHistory(ctx, source, &ref, limit)
+	if err != nil {
+		return nil, err
+	}
+	history := buildHistory(commits, source, &ref)
+
+	data, err := json.Marshal(history)
+	if err != nil {
+		return nil, fmt.Errorf("marshaling history: %w", err)
+	}
+	entry := &cache.CachedContent{Content: string(data), LastUpdated: time.Now()}
+	if ref.Pinned {
+		entry.Pinned = true
+		if err := cache.SetCachedDocument(ctx, key, entry); err != nil {
+			fmt.Printf("Warning: failed to cache history: %v\n", err)
+		}
+		return history, nil
+	}
+	if err := cache.SetCachedContent(ctx, key, entry); err != nil {
+		fmt.Printf("Warning: failed to cache history: %v\n", err)
+		return history, nil
+	}
+
+	err = cache.IndexDocument(ctx, ref.RepositoryKey(), cache.DocumentIndexEntry{
+		Key:    key,
+		URL:    url,
+		Format: historyIndexFormat,
+		Branch: ref.Branch,
+		Path:   ref.Path,
+	})
+	if err != nil {
+		fmt.Printf("Warning: failed to index cached history: %v\n", err)
+	}
+
+	return history, nil
+}
+
+// buildHistory converts commits to the response model and collects their distinct authors,
+// most recent first. Authors are matched by account when known and by name otherwise.
+func buildHistory(commits []fetcher.Commit, source fetcher.ContentSource, ref *fetcher.DocumentRef) *documentHistory {
+	linker, _ := source.(parser.ReferenceLinker)
+
+	history := &documentHistory{
+		Commits:      make([]models.CommitInfo, 0, len(commits)),
+		Contributors: []models.Contributor{},
+	}
+	contributors := make(map[string]int) // Index in history.Contributors by author key
+
+	for _, commit := range commits {
+		history.Commits = append(history.Commits, models.CommitInfo{
+			SHA:         commit.SHA,
+			Message:     commit.Message,
+			Author:      commit.AuthorName,
+			AuthorLogin: commit.AuthorLogin,
+			AvatarURL:   commit.AvatarURL,
+			Date:        commit.Date,
+			URL:         commit.URL,
+		})
+
+		key := "name:" + strings.ToLower(commit.AuthorName)
+		if commit.AuthorLogin != "" {
+			key = "login:" + strings.ToLower(commit.AuthorLogin)
+		}
+		if i, seen := contributors[key]; seen {
+			history.Contributors[i].Commits++
+			continue
+		}
+
+		contributor := models.Contributor{
+			Name:      commit.AuthorName,
+			Login:     commit.AuthorLogin,
+			AvatarURL: commit.AvatarURL,
+			Commits:   1,
+		}
+		if linker != nil && commit.AuthorLogin != "" {
+			contributor.ProfileURL = linker.UserURL(ref.Host, commit.AuthorLogin)
+		}
+		contributors[key] = len(history.Contributors)
+		history.Contributors = append(history.Contributors, contributor)
+	}
+
+	return history
+}
+
+// apply adds the history to a document's metadata
+func (h *documentHistory) apply(doc *models.MarkdownDocument) {
+	if h == nil {
+		return
+	}
+	if h.unavailable {
+		doc.Metadata.Partial = true
+		return
+	}
+	doc.Metadata.Commits = h.Commits
+	doc.Metadata.Contributors = h.Contributors
+}
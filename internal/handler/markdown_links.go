@@ -0,0 +1,76 @@
+package handler
+
+import (
+	"net/url"
+	"os"
+	"path"
+	"prosamik-backend/internal/fetcher"
+	"strings"
+)
+
+// defaultLinkTemplate routes linked Markdown documents back through /md
+const defaultLinkTemplate = "/md?url={url}"
+
+// documentLinkRewriter returns a function rewriting the repository-relative links of a document.
+// Links to Markdown files follow MD_LINK_TEMPLATE, which may use the {url}, {owner}, {repo},
+// {branch} and {path} placeholders; links to other files point at the file on the source.
+// Anchors are kept, and absolute or anchor-only links are left untouched.
+func documentLinkRewriter(source fetcher.ContentSource, ref *fetcher.DocumentRef) func(string) string {
+	template := os.Getenv("MD_LINK_TEMPLATE")
+	if template == "" {
+		template = defaultLinkTemplate
+	}
+	markdownDir := path.Dir(ref.Path)
+
+	return func(destination string) string {
+		u, err := url.Parse(destination)
+		if err != nil || u.Scheme != "" || u.Host != "" || u.Path == "" {
+			return destination
+		}
+
+		// Like GitHub, a leading slash refers to the repository root
+		filePath := u.Path
+		if !strings.HasPrefix(filePath, "/") {
+			filePath = path.Join(markdownDir, filePath)
+		}
+		filePath = strings.TrimPrefix(path.Clean("/"+filePath), "/")
+		if filePath == "" {
+			return destination
+		}
+
+		fragment := ""
+		if u.Fragment != "" {
+			fragment = "#" + u.EscapedFragment()
+		}
+
+		blobURL := source.BlobURL(ref, filePath)
+
+		if isMarkdownPath(filePath) {
+			return strings.NewReplacer(
+				"{url}", url.QueryEscape(blobURL),
+				"{owner}", url.PathEscape(ref.Owner),
+				"{repo}", url.PathEscape(ref.Repo),
+				"{branch}", url.PathEscape(ref.Branch),
+				"{path}", filePath,
+			).Replace(template) + fragment
+		}
+
+		// Files without a web page, such as local documents, fall back to their raw URL
+		if !strings.HasPrefix(blobURL, "http://") && !strings.HasPrefix(blobURL, "https://") {
+			blobURL = source.RawFileURL(ref, filePath)
+		}
+		if blobURL == "" {
+			return destination
+		}
+		return blobURL + fragment
+	}
+}
+
+// isMarkdownPath reports whether a repository file is a Markdown document or a notebook
+func isMarkdownPath(filePath string) bool {
+	switch strings.ToLower(path.Ext(filePath)) {
+	case ".md", ".markdown", ".ipynb":
+		return true
+	}
+	return false
+}
@@ -0,0 +1,173 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"prosamik-backend/internal/fetcher"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// defaultMaxRenderBytes limits the Markdown accepted by /md/render when MAX_RENDER_BYTES is not set
+const defaultMaxRenderBytes = 512 << 10
+
+// defaultSourceHosts are used when a render request names a source but no host
+var defaultSourceHosts = map[string]string{
+	"github": "github.com",
+	"gitlab": "gitlab.com",
+}
+
+// RenderRequest is the body of POST /md/render. The optional repository context
+// resolves relative images and links and GitHub-style references like /md does.
+type RenderRequest struct {
+	Markdown string `json:"markdown"`
+	Source   string `json:"source,omitempty"` // Content source name, "github" by default
+	Host     string `json:"host,omitempty"`   // Host of the repository for GitLab and Gitea
+	Owner    string `json:"owner,omitempty"`
+	Repo     string `json:"repo,omitempty"`
+	Branch   string `json:"branch,omitempty"` // Defaults to the repository's default branch
+	Path     string `json:"path,omitempty"`   // Path of the document, used for relative URLs
+}
+
+// maxRenderBytes returns the Markdown size limit of /md/render
+func maxRenderBytes() int {
+	if value := os.Getenv("MAX_RENDER_BYTES"); value != "" {
+		if limit, err := strconv.Atoi(value); err == nil && limit > 0 {
+			return limit
+		}
+		fmt.Printf("Warning: invalid MAX_RENDER_BYTES %q, using %d\n", value, defaultMaxRenderBytes)
+	}
+	return defaultMaxRenderBytes
+}
+
+// HandleMarkdownRender renders Markdown sent in the request body through the /md pipeline.
+// Results are never cached since the input is arbitrary.
+func HandleMarkdownRender(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	limit := maxRenderBytes()
+	// Leave room for the JSON envelope and escaping around the Markdown itself
+	r.Body = http.MaxBytesReader(w, r.Body, int64(limit)*2+4096)
+
+	var req RenderRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			http.Error(w, fmt.Sprintf("Markdown exceeds %d bytes", limit), http.StatusRequestEntityTooLarge)
+			return
+		}
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if strings.TrimSpace(req.Markdown) == "" {
+		http.Error(w, "markdown is required", http.StatusBadRequest)
+		return
+	}
+	if len(req.Markdown) > limit {
+		http.Error(w, fmt.Sprintf("Markdown exceeds %d bytes", limit), http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	source, ref, err := renderContext(&req)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if source != nil && ref.Branch == "" {
+		if err := fetcher.ResolveBranch(r.Context(), source, ref); err != nil {
+			if writeRateLimited(w, err) {
+				return
+			}
+			log.Printf("Error resolving default branch for render: %v", err)
+			http.Error(w, "Failed to resolve the default branch; pass branch explicitly", http.StatusBadGateway)
+			return
+		}
+	}
+
+	response, _, err := renderDocument(req.Markdown, source, ref, time.Now())
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Error rendering Markdown: %v", err), http.StatusBadRequest)
+		return
+	}
+	// The caller already has the Markdown
+	response.RawContent = ""
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+	}
+}
+
+// HandleMarkdownPreview shows the dashboard page previewing Markdown through /md/render
+func HandleMarkdownPreview(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	data := PageData{
+		Page: "markdown-preview",
+	}
+	if err := templates.ExecuteTemplate(w, "base", data); err != nil {
+		log.Printf("Template error: %v", err)
+		http.Error(w, "Failed to render template", http.StatusInternalServerError)
+	}
+}
+
+// renderContext builds the source and document ref described by a render request.
+// Without an owner and repository the Markdown is rendered without a source.
+func renderContext(req *RenderRequest) (fetcher.ContentSource, *fetcher.DocumentRef, error) {
+	ref := &fetcher.DocumentRef{
+		Owner:        req.Owner,
+		Repo:         req.Repo,
+		Branch:       req.Branch,
+		Path:         strings.TrimPrefix(req.Path, "/"),
+		ExplicitPath: req.Path != "",
+	}
+	if ref.Path == "" {
+		ref.Path = "README.md"
+	}
+
+	if req.Source == "" && (req.Owner == "" || req.Repo == "") {
+		if req.Host != "" || req.Branch != "" {
+			return nil, nil, fmt.Errorf("owner and repo are required with host or branch")
+		}
+		return nil, ref, nil
+	}
+
+	name := req.Source
+	if name == "" {
+		name = "github"
+	}
+	source, ok := fetcher.SourceByName(name)
+	if !ok {
+		return nil, nil, fmt.Errorf("unknown or unconfigured source: %s", name)
+	}
+	if name != "local" && (req.Owner == "" || req.Repo == "") {
+		return nil, nil, fmt.Errorf("owner and repo are required for source %s", name)
+	}
+
+	ref.Source = name
+	ref.Host = req.Host
+	if ref.Host == "" {
+		ref.Host = defaultSourceHosts[name]
+	}
+	if name == "local" {
+		ref.Host = "local"
+	} else if ref.Host == "" || !source.Matches(&url.URL{Scheme: "https", Host: ref.Host, Path: "/"}) {
+		return nil, nil, fmt.Errorf("host is not configured for source %s", name)
+	}
+
+	return source, ref, nil
+}
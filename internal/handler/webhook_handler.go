@@ -0,0 +1,224 @@
+package handler
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"prosamik-backend/internal/cache"
+	"prosamik-backend/internal/fetcher"
+	"strings"
+)
+
+const (
+	maxWebhookPayloadBytes = 25 << 20 // Matches the largest payload GitHub delivers
+	maxPushCommits         = 2048     // GitHub lists at most this many commits in a push payload
+)
+
+// zeroCommit is the before or after commit of pushes creating or deleting a branch
+var zeroCommit = strings.Repeat("0", 40)
+
+// gitHubPushEvent holds the parts of a push webhook payload used for invalidation
+type gitHubPushEvent struct {
+	Ref        string `json:"ref"`
+	Before     string `json:"before"`
+	After      string `json:"after"`
+	Forced     bool   `json:"forced"`
+	Repository struct {
+		Name  string `json:"name"`
+		Owner struct {
+			Login string `json:"login"`
+			Name  string `json:"name"`
+		} `json:"owner"`
+	} `json:"repository"`
+	Commits []gitHubPushCommit `json:"commits"`
+}
+
+// gitHubPushCommit lists the files touched by a pushed commit
+type gitHubPushCommit struct {
+	Added    []string `json:"added"`
+	Modified []string `json:"modified"`
+	Removed  []string `json:"removed"`
+}
+
+// HandleGitHubWebhook invalidates the cached documents touched by a push.
+// Deliveries must be signed with GITHUB_WEBHOOK_SECRET; WEBHOOK_REWARM=true
+// re-renders the invalidated documents in the background.
+func HandleGitHubWebhook(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	secret := os.Getenv("GITHUB_WEBHOOK_SECRET")
+	if secret == "" {
+		http.Error(w, "Webhook secret is not configured", http.StatusServiceUnavailable)
+		return
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxWebhookPayloadBytes))
+	if err != nil {
+		http.Error(w, "Failed to read payload", http.StatusBadRequest)
+		return
+	}
+
+	if !validWebhookSignature(secret, body, r.Header.Get("X-Hub-Signature-256")) {
+		http.Error(w, "Invalid signature", http.StatusUnauthorized)
+		return
+	}
+
+	switch r.Header.Get("X-GitHub-Event") {
+	case "ping":
+		writeWebhookResponse(w, map[string]interface{}{"status": "pong"})
+		return
+	case "push":
+	default:
+		w.WriteHeader(http.StatusAccepted)
+		writeWebhookResponse(w, map[string]interface{}{"status": "ignored"})
+		return
+	}
+
+	var event gitHubPushEvent
+	if err := json.Unmarshal(body, &event); err != nil {
+		http.Error(w, "Invalid push payload", http.StatusBadRequest)
+		return
+	}
+
+	invalidated, err := invalidatePushedDocuments(r.Context(), &event)
+	if err != nil {
+		fmt.Printf("Error invalidating documents for push: %v\n", err)
+		http.Error(w, "Failed to invalidate cached documents", http.StatusInternalServerError)
+		return
+	}
+
+	rewarm := os.Getenv("WEBHOOK_REWARM") == "true"
+	if rewarm && len(invalidated) > 0 {
+		go rewarmDocuments(invalidated)
+	}
+
+	writeWebhookResponse(w, map[string]interface{}{
+		"status":      "ok",
+		"invalidated": len(invalidated),
+		"rewarming":   rewarm && len(invalidated) > 0,
+	})
+}
+
+// validWebhookSignature checks an X-Hub-Signature-256 header against the payload
+func validWebhookSignature(secret string, body []byte, signature string) bool {
+	received, found := strings.CutPrefix(signature, "sha256=")
+	if !found {
+		return false
+	}
+	decoded, err := hex.DecodeString(received)
+	if err != nil {
+		return false
+	}
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return hmac.Equal(decoded, mac.Sum(nil))
+}
+
+// invalidatePushedDocuments deletes the cached documents of the pushed branch whose files changed.
+// When the payload does not list every changed file, all documents of the branch are deleted.
+func invalidatePushedDocuments(ctx context.Context, event *gitHubPushEvent) ([]cache.DocumentIndexEntry, error) {
+	branch, isBranch := strings.CutPrefix(event.Ref, "refs/heads/")
+	if !isBranch {
+		// Tags do not move cached branch content
+		return nil, nil
+	}
+
+	owner := event.Repository.Owner.Login
+	if owner == "" {
+		owner = event.Repository.Owner.Name
+	}
+	ref := &fetcher.DocumentRef{Source: "github", Host: "github.com", Owner: owner, Repo: event.Repository.Name}
+	repository := ref.RepositoryKey()
+
+	changed := make(map[string]bool)
+	for _, commit := range event.Commits {
+		for _, files := range [][]string{commit.Added, commit.Modified, commit.Removed} {
+			for _, file := range files {
+				changed[file] = true
+			}
+		}
+	}
+
+	entries, err := cache.IndexedDocuments(ctx, repository)
+	if err != nil {
+		return nil, err
+	}
+
+	complete := completePush(event)
+	var touched []cache.DocumentIndexEntry
+	for _, entry := range entries {
+		// Docs navigations list a whole folder and change with any file below it
+		if entry.Branch == branch && (!complete || changed[entry.Path] ||
+			entry.Format == docsIndexFormat && changedBelow(changed, entry.Path)) {
+			touched = append(touched, entry)
+		}
+	}
+
+	if err := cache.InvalidateDocuments(ctx, repository, touched); err != nil {
+		return nil, err
+	}
+	return touched, nil
+}
+
+// completePush reports whether the commits of a push payload list every changed file. GitHub truncates
+// the list at maxPushCommits, and force pushes, branch creations and deletions and pushes of
+// existing commits change files that are not listed.
+func completePush(event *gitHubPushEvent) bool {
+	switch {
+	case event.Forced, len(event.Commits) >= maxPushCommits:
+		return false
+	case event.Before == "" || event.Before == zeroCommit || event.After == "" || event.After == zeroCommit:
+		return false
+	}
+	return len(event.Commits) > 0 || event.Before == event.After
+}
+
+// changedBelow reports whether any changed file lies below dir
+func changedBelow(changed map[string]bool, dir string) bool {
+	for file := range changed {
+		if dir == "" || strings.HasPrefix(file, dir+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// rewarmDocuments renders invalidated documents again so the next reader hits the cache
+func rewarmDocuments(entries []cache.DocumentIndexEntry) {
+	for _, entry := range entries {
+		if _, ok := formatContentTypes[documentFormat(entry.Format)]; !ok {
+			// Histories, docs navigations and diffs are loaded again when they are next requested
+			continue
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), documentLoadTimeout)
+
+		source, ref, err := fetcher.ResolveSource(entry.URL)
+		if err == nil {
+			_, err = loadDocument(ctx, entry.URL, documentFormat(entry.Format), source, ref, nil)
+		}
+		if err != nil {
+			fmt.Printf("Warning: failed to re-warm %s: %v\n", entry.URL, err)
+		}
+
+		cancel()
+	}
+}
+
+// writeWebhookResponse writes a JSON webhook response
+func writeWebhookResponse(w http.ResponseWriter, response map[string]interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		fmt.Printf("Warning: failed to write webhook response: %v\n", err)
+	}
+}
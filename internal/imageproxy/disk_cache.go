@@ -0,0 +1,198 @@
+package imageproxy
+
+import (
+	"bytes"
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Image is an image and the content type it is served with
+type Image struct {
+	Body        []byte
+	ContentType string
+}
+
+// DiskCache stores images on local disk and evicts the least recently used ones
+// once the total size exceeds its cap. Each file holds the content type on its
+// first line followed by the image bytes.
+type DiskCache struct {
+	dir      string
+	maxBytes int64
+
+	mu      sync.Mutex
+	size    int64
+	order   *list.List               // Front is the most recently used entry
+	entries map[string]*list.Element // Cache key to its element in order
+}
+
+// diskEntry is a single cached file
+type diskEntry struct {
+	key  string
+	size int64
+}
+
+// NewDiskCache creates a cache in dir, picking up files left by a previous run
+func NewDiskCache(dir string, maxBytes int64) (*DiskCache, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("creating image cache directory: %w", err)
+	}
+
+	c := &DiskCache{
+		dir:      dir,
+		maxBytes: maxBytes,
+		order:    list.New(),
+		entries:  make(map[string]*list.Element),
+	}
+
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("reading image cache directory: %w", err)
+	}
+
+	// Restore the previous order from modification times, oldest first
+	type existingFile struct {
+		name string
+		info os.FileInfo
+	}
+	var existing []existingFile
+	for _, file := range files {
+		if strings.HasPrefix(file.Name(), "tmp-") {
+			// Left behind by a write that never completed
+			os.Remove(filepath.Join(dir, file.Name()))
+			continue
+		}
+		info, err := file.Info()
+		if err != nil || !info.Mode().IsRegular() || filepath.Ext(file.Name()) != ".img" {
+			continue
+		}
+		existing = append(existing, existingFile{name: file.Name(), info: info})
+	}
+	sort.Slice(existing, func(i, j int) bool {
+		return existing[i].info.ModTime().Before(existing[j].info.ModTime())
+	})
+	for _, file := range existing {
+		key := file.name[:len(file.name)-len(".img")]
+		c.entries[key] = c.order.PushFront(&diskEntry{key: key, size: file.info.Size()})
+		c.size += file.info.Size()
+	}
+
+	c.mu.Lock()
+	c.evict()
+	c.mu.Unlock()
+
+	return c, nil
+}
+
+// Key derives the cache key of an image URL at a given width; width 0 is the original
+func Key(imageURL string, width int) string {
+	sum := sha256.Sum256([]byte(imageURL + "|w=" + strconv.Itoa(width)))
+	return hex.EncodeToString(sum[:])
+}
+
+// Get returns a cached image and marks it as recently used
+func (c *DiskCache) Get(key string) (*Image, bool) {
+	c.mu.Lock()
+	element, ok := c.entries[key]
+	if ok {
+		c.order.MoveToFront(element)
+	}
+	c.mu.Unlock()
+	if !ok {
+		return nil, false
+	}
+
+	data, err := os.ReadFile(c.path(key))
+	if err != nil {
+		c.remove(key)
+		return nil, false
+	}
+
+	// Touch the file so the order survives a restart
+	now := time.Now()
+	os.Chtimes(c.path(key), now, now)
+
+	contentType, body, found := bytes.Cut(data, []byte("\n"))
+	if !found {
+		c.remove(key)
+		return nil, false
+	}
+
+	return &Image{Body: body, ContentType: string(contentType)}, true
+}
+
+// Put stores an image and evicts the least recently used images above the size cap
+func (c *DiskCache) Put(key string, img *Image) error {
+	data := append([]byte(img.ContentType+"\n"), img.Body...)
+	if int64(len(data)) > c.maxBytes {
+		return nil // Never worth evicting the whole cache for one image
+	}
+
+	// Write to a temporary file first so readers never see a partial image
+	tmp, err := os.CreateTemp(c.dir, "tmp-*")
+	if err != nil {
+		return fmt.Errorf("creating cache file: %w", err)
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return fmt.Errorf("writing cache file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("closing cache file: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), c.path(key)); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("storing cache file: %w", err)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if element, ok := c.entries[key]; ok {
+		c.size -= element.Value.(*diskEntry).size
+		c.order.Remove(element)
+	}
+	c.entries[key] = c.order.PushFront(&diskEntry{key: key, size: int64(len(data))})
+	c.size += int64(len(data))
+	c.evict()
+
+	return nil
+}
+
+// evict removes the least recently used files until the cache fits its cap.
+// The caller must hold c.mu.
+func (c *DiskCache) evict() {
+	for c.size > c.maxBytes && c.order.Len() > 0 {
+		entry := c.order.Remove(c.order.Back()).(*diskEntry)
+		delete(c.entries, entry.key)
+		c.size -= entry.size
+		if err := os.Remove(c.path(entry.key)); err != nil && !os.IsNotExist(err) {
+			fmt.Printf("Warning: failed to remove cached image: %v\n", err)
+		}
+	}
+}
+
+// remove drops an entry whose file is missing or unreadable
+func (c *DiskCache) remove(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if element, ok := c.entries[key]; ok {
+		c.size -= element.Value.(*diskEntry).size
+		c.order.Remove(element)
+		delete(c.entries, key)
+	}
+	os.Remove(c.path(key))
+}
+
+func (c *DiskCache) path(key string) string {
+	return filepath.Join(c.dir, key+".img")
+}
@@ -0,0 +1,89 @@
+package imageproxy
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"net/http"
+	"strings"
+
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp" // Register the WebP decoder
+)
+
+// MaxWidth is the widest variant the proxy will produce
+const MaxWidth = 4096
+
+// DetectContentType returns the content type of an image, trusting the declared type
+// only when the bytes cannot be sniffed
+func DetectContentType(body []byte, declared string) string {
+	sniffed := http.DetectContentType(body)
+	if strings.HasPrefix(sniffed, "image/") {
+		return sniffed
+	}
+
+	// SVG is XML, which sniffs as text
+	trimmed := bytes.TrimSpace(body)
+	if bytes.HasPrefix(trimmed, []byte("<svg")) ||
+		(bytes.HasPrefix(trimmed, []byte("<?xml")) && bytes.Contains(trimmed[:min(len(trimmed), 1024)], []byte("<svg"))) {
+		return "image/svg+xml"
+	}
+
+	if declared, _, _ = strings.Cut(declared, ";"); strings.HasPrefix(declared, "image/") {
+		return strings.TrimSpace(declared)
+	}
+	return sniffed
+}
+
+// Resize scales an image down to the given width, keeping its aspect ratio.
+// Images that are already narrow enough, vector images and animated GIFs are returned unchanged.
+func Resize(img *Image, width int) (*Image, error) {
+	switch img.ContentType {
+	case "image/png", "image/jpeg", "image/webp":
+	case "image/gif":
+		// Only a single frame survives scaling, so animations are kept as they are
+		anim, err := gif.DecodeAll(bytes.NewReader(img.Body))
+		if err != nil || len(anim.Image) > 1 {
+			return img, nil
+		}
+	default:
+		return img, nil
+	}
+
+	src, _, err := image.Decode(bytes.NewReader(img.Body))
+	if err != nil {
+		return nil, fmt.Errorf("decoding image: %w", err)
+	}
+
+	bounds := src.Bounds()
+	if width <= 0 || width >= bounds.Dx() {
+		return img, nil
+	}
+	height := max(1, bounds.Dy()*width/bounds.Dx())
+
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), src, bounds, draw.Over, nil)
+
+	// JPEG stays JPEG; everything else becomes PNG since there is no WebP or GIF encoder here
+	var buf bytes.Buffer
+	contentType := "image/png"
+	if img.ContentType == "image/jpeg" {
+		contentType = "image/jpeg"
+		err = jpeg.Encode(&buf, dst, &jpeg.Options{Quality: 85})
+	} else {
+		err = png.Encode(&buf, dst)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("encoding image: %w", err)
+	}
+
+	// Keep the original when re-encoding did not make it smaller
+	if buf.Len() >= len(img.Body) {
+		return img, nil
+	}
+
+	return &Image{Body: buf.Bytes(), ContentType: contentType}, nil
+}
@@ -0,0 +1,320 @@
+package middleware
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"prosamik-backend/internal/auth"
+	"prosamik-backend/internal/database"
+	"prosamik-backend/internal/repository"
+	"prosamik-backend/pkg/models"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// APIKeyHeader is the request header carrying an API key
+const APIKeyHeader = "X-API-Key"
+
+const (
+	defaultAnonymousRateLimit  = 60    // Anonymous requests per client and minute
+	defaultAnonymousDailyQuota = 1000  // Anonymous requests per client and UTC day
+	defaultImageRateLimit      = 300   // Uncached images per client and minute
+	defaultImageDailyQuota     = 10000 // Uncached images per client and UTC day
+
+	// apiKeyCacheTTL is how long a looked-up key is trusted, so a revocation applies within it
+	apiKeyCacheTTL = 30 * time.Second
+)
+
+// AnonymousTier holds the limits of requests without an API key; 0 means unlimited
+type AnonymousTier struct {
+	RateLimit  int // Requests per client and minute, MD_RATE_LIMIT for documents
+	DailyQuota int // Requests per client and UTC day, MD_ANONYMOUS_DAILY_QUOTA for documents
+}
+
+// AnonymousTierFromEnv reads the limits of the anonymous tier
+func AnonymousTierFromEnv() AnonymousTier {
+	return AnonymousTier{
+		RateLimit:  envLimit("MD_RATE_LIMIT", defaultAnonymousRateLimit),
+		DailyQuota: envLimit("MD_ANONYMOUS_DAILY_QUOTA", defaultAnonymousDailyQuota),
+	}
+}
+
+// ImageTierFromEnv reads the limits of the image proxy
+func ImageTierFromEnv() AnonymousTier {
+	return AnonymousTier{
+		RateLimit:  envLimit("IMG_RATE_LIMIT", defaultImageRateLimit),
+		DailyQuota: envLimit("IMG_DAILY_QUOTA", defaultImageDailyQuota),
+	}
+}
+
+// envLimit reads a non-negative limit from the environment
+func envLimit(name string, fallback int) int {
+	value := os.Getenv(name)
+	if value == "" {
+		return fallback
+	}
+	limit, err := strconv.Atoi(value)
+	if err != nil || limit < 0 {
+		fmt.Printf("Warning: invalid %s %q, using %d\n", name, value, fallback)
+		return fallback
+	}
+	return limit
+}
+
+// windowCount counts the requests of a client in a fixed window
+type windowCount struct {
+	start time.Time
+	count int
+}
+
+type cachedAPIKey struct {
+	key     *models.APIKey // nil for keys that do not exist
+	expires time.Time
+}
+
+// APIKeyLimiter limits the document endpoints. Requests with an API key get the key's rate limit
+// and daily quota, counted in the database; the others get the anonymous tier, counted per client in memory.
+type APIKeyLimiter struct {
+	tierFromEnv func() AnonymousTier // Reads the anonymous tier; AnonymousTierFromEnv when nil
+	ignoreKeys  bool                 // Apply the anonymous tier to every request
+
+	once      sync.Once
+	anonymous AnonymousTier
+	proxies   []*net.IPNet // TRUSTED_PROXIES, whose X-Forwarded-For is honoured
+
+	mu      sync.Mutex
+	keys    map[string]cachedAPIKey // By key hash
+	minutes map[string]*windowCount // By key ID or client IP
+	days    map[string]*windowCount // By client IP
+	pruned  time.Time
+
+	anonymousDay      time.Time
+	anonymousRequests int
+}
+
+// DocumentLimiter limits the public document endpoints
+var DocumentLimiter = &APIKeyLimiter{}
+
+// ImageLimiter limits the image proxy apart from the documents, since one page loads many images.
+// Browsers send no API key with images, so every client gets the same limits.
+var ImageLimiter = &APIKeyLimiter{tierFromEnv: ImageTierFromEnv, ignoreKeys: true}
+
+// AnonymousTier returns the limits of requests without an API key
+func (l *APIKeyLimiter) AnonymousTier() AnonymousTier {
+	l.init()
+	return l.anonymous
+}
+
+// AnonymousUsage returns the anonymous requests of the current UTC day and the clients that made them
+func (l *APIKeyLimiter) AnonymousUsage() (requests, clients int) {
+	l.init()
+	day := utcDay(time.Now())
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if !l.anonymousDay.Equal(day) {
+		return 0, 0
+	}
+	for _, count := range l.days {
+		if count.start.Equal(day) {
+			clients++
+		}
+	}
+	return l.anonymousRequests, clients
+}
+
+// Init reads the limiter's configuration, so that a missing TRUSTED_PROXIES is reported at startup
+func (l *APIKeyLimiter) Init() {
+	l.init()
+}
+
+func (l *APIKeyLimiter) init() {
+	l.once.Do(func() {
+		if l.tierFromEnv == nil {
+			l.tierFromEnv = AnonymousTierFromEnv
+		}
+		l.anonymous = l.tierFromEnv()
+		l.proxies = loadTrustedProxies()
+		l.keys = make(map[string]cachedAPIKey)
+		l.minutes = make(map[string]*windowCount)
+		l.days = make(map[string]*windowCount)
+	})
+}
+
+// Middleware applies the limits of the request's API key, or the anonymous tier without one
+func (l *APIKeyLimiter) Middleware(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		l.init()
+		if r.Method == http.MethodOptions {
+			next(w, r)
+			return
+		}
+
+		if l.Charge(w, r, 1) {
+			next(w, r)
+		}
+	}
+}
+
+// Charge counts n requests against the limits of the request's API key, or the anonymous tier
+// without one, so endpoints doing the work of several documents pay for each of them.
+// Requests over a limit are answered and false is returned.
+func (l *APIKeyLimiter) Charge(w http.ResponseWriter, r *http.Request, n int) bool {
+	l.init()
+	if n <= 0 {
+		return true
+	}
+	if key := strings.TrimSpace(r.Header.Get(APIKeyHeader)); key != "" && !l.ignoreKeys {
+		return l.allowKey(w, key, n)
+	}
+	return l.allowAnonymous(w, l.clientIP(r), n)
+}
+
+// allowKey checks an API key and counts n requests against its limits
+func (l *APIKeyLimiter) allowKey(w http.ResponseWriter, key string, n int) bool {
+	apiKey, err := l.lookupKey(auth.HashAPIKey(key))
+	if err != nil {
+		fmt.Printf("Error looking up API key: %v\n", err)
+		http.Error(w, "API keys are unavailable", http.StatusServiceUnavailable)
+		return false
+	}
+	if apiKey == nil || apiKey.RevokedAt != nil {
+		http.Error(w, "Invalid API key", http.StatusUnauthorized)
+		return false
+	}
+
+	now := time.Now()
+	if apiKey.RateLimit > 0 && l.hit(l.minutes, fmt.Sprintf("key:%d", apiKey.ID), now, time.Minute, n) > apiKey.RateLimit {
+		http.Error(w, "Too many requests", http.StatusTooManyRequests)
+		return false
+	}
+
+	// Requests over the quota are counted as well, so the dashboard shows the demand
+	requests, err := repository.NewAPIKeyRepository().IncrementUsage(apiKey.ID, now.UTC(), n)
+	if err != nil {
+		fmt.Printf("Warning: failed to count usage of API key %s: %v\n", apiKey.Prefix, err)
+		return true
+	}
+	return allowQuota(w, apiKey.DailyQuota, requests, now)
+}
+
+// allowAnonymous counts n requests without an API key against the anonymous tier
+func (l *APIKeyLimiter) allowAnonymous(w http.ResponseWriter, ip string, n int) bool {
+	now := time.Now()
+	day := utcDay(now)
+
+	l.mu.Lock()
+	if !l.anonymousDay.Equal(day) {
+		l.anonymousDay, l.anonymousRequests = day, 0
+	}
+	l.anonymousRequests += n
+	l.mu.Unlock()
+
+	if l.anonymous.RateLimit > 0 && l.hit(l.minutes, "ip:"+ip, now, time.Minute, n) > l.anonymous.RateLimit {
+		http.Error(w, "Too many requests", http.StatusTooManyRequests)
+		return false
+	}
+	if l.anonymous.DailyQuota == 0 {
+		return true
+	}
+	return allowQuota(w, l.anonymous.DailyQuota, l.hit(l.days, ip, day, 24*time.Hour, n), now)
+}
+
+// allowQuota reports the daily quota in the response headers and rejects requests over it
+// until the next UTC midnight; a quota of 0 is unlimited
+func allowQuota(w http.ResponseWriter, quota, requests int, now time.Time) bool {
+	if quota == 0 {
+		return true
+	}
+
+	w.Header().Set("X-Quota-Limit", strconv.Itoa(quota))
+	w.Header().Set("X-Quota-Remaining", strconv.Itoa(max(quota-requests, 0)))
+	if requests <= quota {
+		return true
+	}
+
+	reset := utcDay(now).Add(24 * time.Hour)
+	w.Header().Set("Retry-After", strconv.Itoa(int(reset.Sub(now).Seconds())+1))
+	http.Error(w, "Daily quota exceeded", http.StatusTooManyRequests)
+	return false
+}
+
+// lookupKey returns the API key with the given hash, caching the result for apiKeyCacheTTL
+func (l *APIKeyLimiter) lookupKey(hash string) (*models.APIKey, error) {
+	now := time.Now()
+	l.mu.Lock()
+	cached, ok := l.keys[hash]
+	l.mu.Unlock()
+	if ok && now.Before(cached.expires) {
+		return cached.key, nil
+	}
+
+	if database.DB == nil {
+		return nil, fmt.Errorf("database is not initialized")
+	}
+	key, err := repository.NewAPIKeyRepository().GetAPIKeyByHash(hash)
+	if err != nil {
+		return nil, err
+	}
+
+	l.mu.Lock()
+	l.keys[hash] = cachedAPIKey{key: key, expires: now.Add(apiKeyCacheTTL)}
+	l.mu.Unlock()
+	return key, nil
+}
+
+// ForgetKeys drops the cached keys so a revocation applies to the next request
+func (l *APIKeyLimiter) ForgetKeys() {
+	l.init()
+	l.mu.Lock()
+	l.keys = make(map[string]cachedAPIKey)
+	l.mu.Unlock()
+}
+
+// hit counts n requests in the window of id and returns the requests of that window.
+// A window older than length is replaced by one beginning at start.
+func (l *APIKeyLimiter) hit(windows map[string]*windowCount, id string, start time.Time, length time.Duration, n int) int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.prune(time.Now())
+
+	count, ok := windows[id]
+	if !ok || start.Sub(count.start) >= length {
+		count = &windowCount{start: start}
+		windows[id] = count
+	}
+	count.count += n
+	return count.count
+}
+
+// prune drops the expired windows and cached keys once a minute so the maps don't grow without bound
+func (l *APIKeyLimiter) prune(now time.Time) {
+	if now.Sub(l.pruned) < time.Minute {
+		return
+	}
+	l.pruned = now
+
+	for id, count := range l.minutes {
+		if now.Sub(count.start) > time.Minute {
+			delete(l.minutes, id)
+		}
+	}
+	for id, count := range l.days {
+		if count.start.Before(utcDay(now)) {
+			delete(l.days, id)
+		}
+	}
+	for hash, cached := range l.keys {
+		if now.After(cached.expires) {
+			delete(l.keys, hash)
+		}
+	}
+}
+
+// utcDay returns the start of the UTC day of t
+func utcDay(t time.Time) time.Time {
+	return t.UTC().Truncate(24 * time.Hour)
+}
@@ -3,6 +3,7 @@ package middleware
 import (
 	"net/http"
 	"prosamik-backend/internal/auth"
+	"strings"
 )
 
 func AuthMiddleware(next http.HandlerFunc) http.HandlerFunc {
@@ -27,3 +28,30 @@ func AuthMiddleware(next http.HandlerFunc) http.HandlerFunc {
 		next.ServeHTTP(w, r)
 	}
 }
+
+// APIAuthMiddleware authenticates API requests with a JWT sent as a bearer token or in the
+// dashboard's auth_token cookie, answering 401 instead of redirecting to the login page
+func APIAuthMiddleware(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		token := ""
+		if header := r.Header.Get("Authorization"); strings.HasPrefix(header, "Bearer ") {
+			token = strings.TrimPrefix(header, "Bearer ")
+		} else if cookie, err := r.Cookie("auth_token"); err == nil {
+			token = cookie.Value
+		}
+
+		if token == "" {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			http.Error(w, "Authentication required", http.StatusUnauthorized)
+			return
+		}
+
+		if _, err := auth.ValidateToken(token); err != nil {
+			w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+			http.Error(w, "Invalid or expired token", http.StatusUnauthorized)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	}
+}
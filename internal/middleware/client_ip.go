@@ -0,0 +1,104 @@
+package middleware
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+)
+
+var (
+	trustedProxies     []*net.IPNet
+	trustedProxiesOnce sync.Once
+)
+
+// loadTrustedProxies reads TRUSTED_PROXIES once for all limiters
+func loadTrustedProxies() []*net.IPNet {
+	trustedProxiesOnce.Do(func() {
+		trustedProxies = trustedProxiesFromEnv()
+	})
+	return trustedProxies
+}
+
+// trustedProxiesFromEnv parses TRUSTED_PROXIES, a comma separated list of addresses and CIDR ranges.
+// "none" declares that clients connect directly. Without the variable every anonymous client
+// behind a proxy shares the proxy's address, so that is reported loudly.
+func trustedProxiesFromEnv() []*net.IPNet {
+	value := strings.TrimSpace(os.Getenv("TRUSTED_PROXIES"))
+	switch strings.ToLower(value) {
+	case "":
+		fmt.Println("Warning: TRUSTED_PROXIES is not set, so anonymous document clients are told apart by the " +
+			"connection address only. Behind a proxy they all share one rate limit and quota; set TRUSTED_PROXIES " +
+			"to the proxy addresses, or to \"none\" when clients connect directly")
+		return nil
+	case "none":
+		return nil
+	}
+
+	var proxies []*net.IPNet
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		if !strings.Contains(entry, "/") {
+			if strings.Contains(entry, ":") {
+				entry += "/128"
+			} else {
+				entry += "/32"
+			}
+		}
+		_, network, err := net.ParseCIDR(entry)
+		if err != nil {
+			fmt.Printf("Warning: ignoring invalid TRUSTED_PROXIES entry %q\n", entry)
+			continue
+		}
+		proxies = append(proxies, network)
+	}
+	return proxies
+}
+
+// clientIP returns the client address of a request without its port. X-Forwarded-For is only
+// trusted when the connection comes from a trusted proxy, and then the right-most address not
+// belonging to a trusted proxy is taken, since clients can prepend anything.
+func (l *APIKeyLimiter) clientIP(r *http.Request) string {
+	ip, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		ip = r.RemoteAddr
+	}
+	if !l.trustedProxy(ip) {
+		return ip
+	}
+
+	var hops []string
+	for _, header := range r.Header.Values("X-Forwarded-For") {
+		hops = append(hops, strings.Split(header, ",")...)
+	}
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop := strings.TrimSpace(hops[i])
+		if net.ParseIP(hop) == nil {
+			break
+		}
+		ip = hop
+		if !l.trustedProxy(hop) {
+			break
+		}
+	}
+	return ip
+}
+
+// trustedProxy reports whether ip belongs to one of the trusted proxies
+func (l *APIKeyLimiter) trustedProxy(ip string) bool {
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return false
+	}
+	for _, network := range l.proxies {
+		if network.Contains(parsed) {
+			return true
+		}
+	}
+	return false
+}
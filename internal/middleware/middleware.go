@@ -19,7 +19,8 @@ func CORSMiddleware(next http.HandlerFunc) http.HandlerFunc {
 		// Set CORS headers
 		w.Header().Set("Access-Control-Allow-Origin", "*")
 		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, X-Requested-With")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, X-Requested-With, Authorization, X-API-Key")
+		w.Header().Set("Access-Control-Expose-Headers", "X-Quota-Limit, X-Quota-Remaining, Retry-After, Warning")
 		w.Header().Set("Access-Control-Allow-Credentials", "true")
 
 		// Handle preflight
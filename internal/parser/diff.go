@@ -0,0 +1,149 @@
+package parser
+
+import (
+	"regexp"
+	"strings"
+)
+
+// DiffKind is the kind of a diff operation
+type DiffKind string
+
+const (
+	DiffEqual  DiffKind = "equal"
+	DiffInsert DiffKind = "insert"
+	DiffDelete DiffKind = "delete"
+)
+
+// DiffOp is one unit of a diff; consecutive units of the same kind are kept separate
+type DiffOp struct {
+	Kind DiffKind
+	Text string
+}
+
+// maxDiffCells bounds the table of the longest common subsequence. Inputs that differ
+// in more units than that are reported as a deletion of the old units and an insertion of the new.
+const maxDiffCells = 4 << 20
+
+// fencePattern matches the opening or closing line of a fenced code block
+var fencePattern = regexp.MustCompile("^ {0,3}(`{3,}|~{3,})")
+
+// MarkdownBlocks splits Markdown into its blank-line separated blocks, keeping fenced code blocks whole
+func MarkdownBlocks(input string) []string {
+	var blocks []string
+	var current []string
+	fence := ""
+
+	flush := func() {
+		if len(current) > 0 {
+			blocks = append(blocks, strings.Join(current, "\n"))
+			current = nil
+		}
+	}
+
+	for _, line := range MarkdownLines(input) {
+		if match := fencePattern.FindStringSubmatch(line); match != nil {
+			switch {
+			case fence == "":
+				fence = match[1]
+			case strings.HasPrefix(match[1], fence):
+				fence = ""
+			}
+		}
+		if fence == "" && strings.TrimSpace(line) == "" {
+			flush()
+			continue
+		}
+		current = append(current, line)
+	}
+	flush()
+
+	return blocks
+}
+
+// MarkdownLines splits Markdown into lines without their line endings
+func MarkdownLines(input string) []string {
+	input = strings.ReplaceAll(input, "\r\n", "\n")
+	input = strings.TrimSuffix(input, "\n")
+	if input == "" {
+		return nil
+	}
+	return strings.Split(input, "\n")
+}
+
+// Diff returns the operations turning a into b, keeping the longest common subsequence
+func Diff(a, b []string) []DiffOp {
+	// Common prefixes and suffixes need no table
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	var ops []DiffOp
+	for _, text := range a[:prefix] {
+		ops = append(ops, DiffOp{Kind: DiffEqual, Text: text})
+	}
+	ops = append(ops, diffMiddle(a[prefix:len(a)-suffix], b[prefix:len(b)-suffix])...)
+	for _, text := range a[len(a)-suffix:] {
+		ops = append(ops, DiffOp{Kind: DiffEqual, Text: text})
+	}
+	return ops
+}
+
+// diffMiddle diffs the differing middle parts of two inputs through their longest common subsequence
+func diffMiddle(a, b []string) []DiffOp {
+	var ops []DiffOp
+	if (len(a)+1)*(len(b)+1) > maxDiffCells {
+		for _, text := range a {
+			ops = append(ops, DiffOp{Kind: DiffDelete, Text: text})
+		}
+		for _, text := range b {
+			ops = append(ops, DiffOp{Kind: DiffInsert, Text: text})
+		}
+		return ops
+	}
+
+	// lcs[i][j] is the length of the longest common subsequence of a[i:] and b[j:]
+	lcs := make([][]int32, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int32, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			switch {
+			case a[i] == b[j]:
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			case lcs[i+1][j] >= lcs[i][j+1]:
+				lcs[i][j] = lcs[i+1][j]
+			default:
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			ops = append(ops, DiffOp{Kind: DiffEqual, Text: a[i]})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			ops = append(ops, DiffOp{Kind: DiffDelete, Text: a[i]})
+			i++
+		default:
+			ops = append(ops, DiffOp{Kind: DiffInsert, Text: b[j]})
+			j++
+		}
+	}
+	for ; i < len(a); i++ {
+		ops = append(ops, DiffOp{Kind: DiffDelete, Text: a[i]})
+	}
+	for ; j < len(b); j++ {
+		ops = append(ops, DiffOp{Kind: DiffInsert, Text: b[j]})
+	}
+	return ops
+}
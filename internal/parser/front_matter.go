@@ -0,0 +1,177 @@
+package parser
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// FrontMatter holds the metadata fields of a YAML (---) or TOML (+++) front matter block.
+// Fields missing from the block are left empty.
+type FrontMatter struct {
+	Title       string
+	Description string
+	Date        time.Time
+	Tags        []string
+	CoverImage  string
+	Canonical   string
+	Draft       bool
+}
+
+// frontMatterPattern matches a front matter block at the very start of a document
+var frontMatterPattern = regexp.MustCompile(`\A\x{FEFF}?(---|\+\+\+)[ \t]*\r?\n(?:((?s:.*?))\r?\n)?(---|\+\+\+|\.\.\.)[ \t]*(?:\r?\n|\z)`)
+
+// Field names accepted for each front matter field, covering the common static site generators
+var (
+	titleKeys       = []string{"title"}
+	descriptionKeys = []string{"description", "summary", "excerpt"}
+	dateKeys        = []string{"date", "published", "publishDate", "publish_date", "pubDate"}
+	tagsKeys        = []string{"tags", "keywords", "categories"}
+	coverKeys       = []string{"cover", "coverImage", "cover_image", "image", "banner"}
+	canonicalKeys   = []string{"canonical", "canonicalURL", "canonicalUrl", "canonical_url"}
+	draftKeys       = []string{"draft"}
+)
+
+// dateLayouts are the date formats accepted in front matter strings
+var dateLayouts = []string{time.RFC3339, "2006-01-02T15:04:05", "2006-01-02 15:04:05", "2006-01-02 15:04", "2006-01-02"}
+
+// ExtractFrontMatter splits an optional front matter block from a Markdown document.
+// It returns nil and the unchanged input when the document has no front matter.
+func ExtractFrontMatter(input string) (*FrontMatter, string, error) {
+	match := frontMatterPattern.FindStringSubmatchIndex(input)
+	if match == nil {
+		return nil, input, nil
+	}
+
+	delimiter := input[match[2]:match[3]]
+	closing := input[match[6]:match[7]]
+	if (delimiter == "+++") != (closing == "+++") {
+		return nil, input, nil
+	}
+	body := input[match[1]:]
+
+	var block string
+	if match[4] >= 0 {
+		block = input[match[4]:match[5]]
+	}
+
+	fields := make(map[string]interface{})
+	var err error
+	if delimiter == "+++" {
+		_, err = toml.Decode(block, &fields)
+	} else {
+		err = yaml.Unmarshal([]byte(block), &fields)
+	}
+	if err != nil {
+		return nil, input, fmt.Errorf("parsing front matter: %w", err)
+	}
+
+	fm := &FrontMatter{
+		Title:       stringField(fields, titleKeys),
+		Description: stringField(fields, descriptionKeys),
+		Date:        dateField(fields, dateKeys),
+		Tags:        listField(fields, tagsKeys),
+		CoverImage:  coverField(fields),
+		Canonical:   stringField(fields, canonicalKeys),
+		Draft:       boolField(fields, draftKeys),
+	}
+
+	return fm, body, nil
+}
+
+// lookupField returns the first of keys present in fields, ignoring case
+func lookupField(fields map[string]interface{}, keys []string) (interface{}, bool) {
+	for _, key := range keys {
+		for name, value := range fields {
+			if strings.EqualFold(name, key) && value != nil {
+				return value, true
+			}
+		}
+	}
+	return nil, false
+}
+
+func stringField(fields map[string]interface{}, keys []string) string {
+	value, ok := lookupField(fields, keys)
+	if !ok {
+		return ""
+	}
+	switch v := value.(type) {
+	case string:
+		return strings.TrimSpace(v)
+	case map[string]interface{}, []interface{}:
+		return ""
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+func dateField(fields map[string]interface{}, keys []string) time.Time {
+	value, ok := lookupField(fields, keys)
+	if !ok {
+		return time.Time{}
+	}
+
+	switch v := value.(type) {
+	case time.Time:
+		return v
+	case string:
+		for _, layout := range dateLayouts {
+			if t, err := time.Parse(layout, strings.TrimSpace(v)); err == nil {
+				return t
+			}
+		}
+	}
+	return time.Time{}
+}
+
+// listField accepts a list or a comma separated string
+func listField(fields map[string]interface{}, keys []string) []string {
+	value, ok := lookupField(fields, keys)
+	if !ok {
+		return nil
+	}
+
+	var items []string
+	switch v := value.(type) {
+	case []interface{}:
+		for _, item := range v {
+			if s := strings.TrimSpace(fmt.Sprint(item)); s != "" {
+				items = append(items, s)
+			}
+		}
+	case string:
+		items = splitList(v, ",")
+	}
+	return items
+}
+
+func boolField(fields map[string]interface{}, keys []string) bool {
+	value, ok := lookupField(fields, keys)
+	if !ok {
+		return false
+	}
+	switch v := value.(type) {
+	case bool:
+		return v
+	case string:
+		return strings.EqualFold(strings.TrimSpace(v), "true")
+	}
+	return false
+}
+
+// coverField also accepts the nested form used by Hugo themes (cover: {image: ...})
+func coverField(fields map[string]interface{}) string {
+	value, ok := lookupField(fields, coverKeys)
+	if !ok {
+		return ""
+	}
+	if nested, ok := value.(map[string]interface{}); ok {
+		return stringField(nested, []string{"image", "src", "url"})
+	}
+	return stringField(fields, coverKeys)
+}
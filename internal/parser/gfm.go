@@ -0,0 +1,223 @@
+package parser
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+
+	"github.com/gomarkdown/markdown/ast"
+	"github.com/kyokomi/emoji/v2"
+)
+
+// ReferenceLinker builds the URLs that GitHub-style references link to.
+// Content sources implement it for the hosts they serve.
+type ReferenceLinker interface {
+	IssueURL(host, owner, repo, number string) string
+	UserURL(host, user string) string
+	CommitURL(host, owner, repo, sha string) string
+}
+
+var (
+	// alertPattern matches the marker line opening a GitHub alert blockquote
+	alertPattern = regexp.MustCompile(`^\[!(?i:(note|tip|important|warning|caution))\][ \t]*(?:\n|$)`)
+	// taskPattern matches the checkbox opening a task list item
+	taskPattern = regexp.MustCompile(`^\[([ xX])\][ \t]+`)
+	// emojiPattern matches :shortcode: emoji
+	emojiPattern = regexp.MustCompile(`:[a-z0-9_+\-]+:`)
+	// referencePattern matches owner/repo#123, #123, @user and commit SHA references
+	referencePattern = regexp.MustCompile(
+		`([A-Za-z0-9][A-Za-z0-9-]*/[A-Za-z0-9._-]+)?#(\d+)\b|@([A-Za-z0-9][A-Za-z0-9-]{0,38})\b|\b([0-9a-f]{7,40})\b`)
+)
+
+// gfmState remembers the nodes that render differently from plain CommonMark
+type gfmState struct {
+	alerts    map[ast.Node]string // Blockquotes rendered as alerts, with their alert type
+	taskItems map[ast.Node]bool   // List items rendered as tasks, with their checked state
+}
+
+// applyGFM rewrites the AST for GitHub-flavored alerts, task lists, emoji and references
+func applyGFM(root ast.Node, opts Options) *gfmState {
+	state := &gfmState{
+		alerts:    make(map[ast.Node]string),
+		taskItems: make(map[ast.Node]bool),
+	}
+
+	var texts []*ast.Text
+	ast.WalkFunc(root, func(node ast.Node, entering bool) ast.WalkStatus {
+		if !entering {
+			return ast.GoToNext
+		}
+
+		switch n := node.(type) {
+		case *ast.BlockQuote:
+			if alertType, ok := stripLeadingMarker(n, alertPattern); ok {
+				state.alerts[n] = strings.ToLower(alertType)
+			}
+		case *ast.ListItem:
+			if mark, ok := stripLeadingMarker(n, taskPattern); ok {
+				state.taskItems[n] = mark != " "
+			}
+		case *ast.Link, *ast.Image:
+			// Text inside links is never emojified or linked again
+			return ast.SkipChildren
+		case *ast.Text:
+			texts = append(texts, n)
+		}
+		return ast.GoToNext
+	})
+
+	for _, text := range texts {
+		text.Literal = emojiPattern.ReplaceAllFunc(text.Literal, func(code []byte) []byte {
+			if char, ok := emoji.CodeMap()[string(code)]; ok {
+				return []byte(char)
+			}
+			return code
+		})
+
+		if opts.Linker != nil {
+			linkReferences(text, opts)
+		}
+	}
+
+	return state
+}
+
+// stripLeadingMarker removes a marker from the start of the first paragraph of a block
+// and returns the marker's first capture group
+func stripLeadingMarker(block ast.Node, pattern *regexp.Regexp) (string, bool) {
+	paragraph, ok := ast.GetFirstChild(block).(*ast.Paragraph)
+	if !ok {
+		return "", false
+	}
+	text, ok := ast.GetFirstChild(paragraph).(*ast.Text)
+	if !ok {
+		return "", false
+	}
+
+	match := pattern.FindSubmatchIndex(text.Literal)
+	if match == nil {
+		return "", false
+	}
+
+	capture := string(text.Literal[match[2]:match[3]])
+	text.Literal = text.Literal[match[1]:]
+
+	// Drop the paragraph when the marker was all it contained
+	if len(text.Literal) == 0 && len(paragraph.GetChildren()) == 1 {
+		ast.RemoveFromTree(paragraph)
+	}
+
+	return capture, true
+}
+
+// linkReferences splits a text node into text and links for every reference it contains
+func linkReferences(text *ast.Text, opts Options) {
+	literal := text.Literal
+	matches := referencePattern.FindAllSubmatchIndex(literal, -1)
+	if len(matches) == 0 {
+		return
+	}
+
+	var nodes []ast.Node
+	last := 0
+	for _, m := range matches {
+		if m[0] > 0 && isReferenceBoundary(literal[m[0]-1]) {
+			continue
+		}
+
+		var label, destination string
+		switch {
+		case m[4] >= 0: // #123 or owner/repo#123
+			owner, repo := opts.Owner, opts.Repo
+			if m[2] >= 0 {
+				owner, repo, _ = strings.Cut(string(literal[m[2]:m[3]]), "/")
+			}
+			label = string(literal[m[0]:m[1]])
+			destination = opts.Linker.IssueURL(opts.Host, owner, repo, string(literal[m[4]:m[5]]))
+		case m[6] >= 0: // @user
+			label = string(literal[m[0]:m[1]])
+			destination = opts.Linker.UserURL(opts.Host, string(literal[m[6]:m[7]]))
+		case m[8] >= 0: // commit SHA
+			sha := string(literal[m[8]:m[9]])
+			if !strings.ContainsAny(sha, "0123456789") || !strings.ContainsAny(sha, "abcdef") {
+				continue
+			}
+			label = sha[:7]
+			destination = opts.Linker.CommitURL(opts.Host, opts.Owner, opts.Repo, sha)
+		}
+
+		if destination == "" {
+			continue
+		}
+
+		if m[0] > last {
+			nodes = append(nodes, &ast.Text{Leaf: ast.Leaf{Literal: literal[last:m[0]]}})
+		}
+		link := &ast.Link{Destination: []byte(destination)}
+		ast.AppendChild(link, &ast.Text{Leaf: ast.Leaf{Literal: []byte(label)}})
+		nodes = append(nodes, link)
+		last = m[1]
+	}
+
+	if len(nodes) == 0 {
+		return
+	}
+	if last < len(literal) {
+		nodes = append(nodes, &ast.Text{Leaf: ast.Leaf{Literal: literal[last:]}})
+	}
+
+	// Replace the text node with the new nodes in its parent
+	parent := text.GetParent()
+	var children []ast.Node
+	for _, child := range parent.GetChildren() {
+		if child != text {
+			children = append(children, child)
+			continue
+		}
+		for _, node := range nodes {
+			node.SetParent(parent)
+			children = append(children, node)
+		}
+	}
+	parent.SetChildren(children)
+}
+
+// isReferenceBoundary reports whether a reference preceded by c is part of a larger word,
+// such as an email address, a path or a URL fragment
+func isReferenceBoundary(c byte) bool {
+	return c == '_' || c == '/' || c == '@' || c == '.' || c == '#' || c == '-' ||
+		(c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')
+}
+
+// renderHook renders alerts and task list items
+func (s *gfmState) renderHook(w io.Writer, node ast.Node, entering bool) (ast.WalkStatus, bool) {
+	if alertType, ok := s.alerts[node]; ok {
+		if entering {
+			title := strings.ToUpper(alertType[:1]) + alertType[1:]
+			writeHTML(w, fmt.Sprintf("<div class=\"markdown-alert markdown-alert-%s\">\n<p class=\"markdown-alert-title\">%s</p>\n",
+				alertType, title))
+		} else {
+			writeHTML(w, "</div>\n")
+		}
+		return ast.GoToNext, true
+	}
+
+	if checked, ok := s.taskItems[node]; ok && entering {
+		checkbox := `<input type="checkbox" class="task-list-item-checkbox" disabled>`
+		if checked {
+			checkbox = `<input type="checkbox" class="task-list-item-checkbox" disabled checked>`
+		}
+		writeHTML(w, "\n<li class=\"task-list-item\">"+checkbox+" ")
+		return ast.GoToNext, true
+	}
+
+	return ast.GoToNext, false
+}
+
+// writeHTML writes markup from a render hook
+func writeHTML(w io.Writer, markup string) {
+	if _, err := io.WriteString(w, markup); err != nil {
+		fmt.Printf("Warning: failed to write rendered markup: %v\n", err)
+	}
+}
@@ -0,0 +1,201 @@
+package parser
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/alecthomas/chroma/v2"
+	chromahtml "github.com/alecthomas/chroma/v2/formatters/html"
+	"github.com/alecthomas/chroma/v2/lexers"
+	"github.com/alecthomas/chroma/v2/styles"
+	"github.com/gomarkdown/markdown/ast"
+)
+
+const (
+	lightHighlightStyle = "github"
+	darkHighlightStyle  = "github-dark"
+)
+
+// codeInfo holds the options parsed from the info string of a fenced code block
+type codeInfo struct {
+	lang        string
+	highlight   [][2]int // Inclusive line ranges to highlight
+	lineNumbers bool
+}
+
+var (
+	// codeInfoAttrPattern matches the {...} annotation groups of an info string
+	codeInfoAttrPattern = regexp.MustCompile(`\{([^}]*)\}`)
+	// codeLangPattern strips characters that cannot appear in a language name
+	codeLangPattern = regexp.MustCompile(`[^a-z0-9+#_.-]`)
+)
+
+// parseCodeInfo parses info strings such as "go {3-5}", "go {1,4-6} linenos" or "js {linenos}"
+func parseCodeInfo(info string) codeInfo {
+	var ci codeInfo
+
+	attrs := codeInfoAttrPattern.FindAllStringSubmatch(info, -1)
+	rest := codeInfoAttrPattern.ReplaceAllString(info, " ")
+
+	fields := strings.Fields(rest)
+	if len(fields) > 0 {
+		ci.lang = codeLangPattern.ReplaceAllString(strings.ToLower(fields[0]), "")
+		fields = fields[1:]
+	}
+
+	var items []string
+	for _, attr := range attrs {
+		items = append(items, strings.FieldsFunc(attr[1], func(r rune) bool {
+			return r == ',' || r == ' '
+		})...)
+	}
+	items = append(items, fields...)
+
+	for _, item := range items {
+		switch strings.ToLower(item) {
+		case "linenos", "linenos=true", "showlinenumbers", "numberlines":
+			ci.lineNumbers = true
+			continue
+		}
+
+		if lineRange, ok := parseLineRange(item); ok {
+			ci.highlight = append(ci.highlight, lineRange)
+		}
+	}
+
+	return ci
+}
+
+// parseLineRange parses "3" or "3-5" into an inclusive line range
+func parseLineRange(item string) ([2]int, bool) {
+	from, to, isRange := strings.Cut(item, "-")
+
+	start, err := strconv.Atoi(from)
+	if err != nil || start < 1 {
+		return [2]int{}, false
+	}
+	if !isRange {
+		return [2]int{start, start}, true
+	}
+
+	end, err := strconv.Atoi(to)
+	if err != nil || end < start {
+		return [2]int{}, false
+	}
+	return [2]int{start, end}, true
+}
+
+// highlightCodeHook renders fenced code blocks with server-side syntax highlighting
+func highlightCodeHook(w io.Writer, node ast.Node, entering bool) (ast.WalkStatus, bool) {
+	codeBlock, ok := node.(*ast.CodeBlock)
+	if !ok || !codeBlock.IsFenced {
+		return ast.GoToNext, false
+	}
+
+	info := parseCodeInfo(string(codeBlock.Info))
+	if info.lang == "" && len(info.highlight) == 0 && !info.lineNumbers {
+		// Leave plain blocks to the default renderer
+		return ast.GoToNext, false
+	}
+
+	highlighted, err := highlightCode(string(codeBlock.Literal), info)
+	if err != nil {
+		fmt.Printf("Warning: failed to highlight code block: %v\n", err)
+		return ast.GoToNext, false
+	}
+
+	if _, err := io.WriteString(w, highlighted); err != nil {
+		fmt.Printf("Warning: failed to write highlighted code block: %v\n", err)
+	}
+	return ast.GoToNext, true
+}
+
+// highlightCode renders source code as class-based chroma HTML
+func highlightCode(source string, info codeInfo) (string, error) {
+	lexer := lexers.Get(info.lang)
+	if lexer == nil {
+		lexer = lexers.Fallback
+	}
+	lexer = chroma.Coalesce(lexer)
+
+	iterator, err := lexer.Tokenise(nil, source)
+	if err != nil {
+		return "", fmt.Errorf("tokenising code: %w", err)
+	}
+
+	formatter := chromahtml.New(
+		chromahtml.WithClasses(true),
+		chromahtml.WithLineNumbers(info.lineNumbers),
+		chromahtml.HighlightLines(info.highlight),
+		chromahtml.WithPreWrapper(codePreWrapper{lang: info.lang}),
+	)
+
+	var buf bytes.Buffer
+	if err := formatter.Format(&buf, styles.Get(lightHighlightStyle), iterator); err != nil {
+		return "", fmt.Errorf("formatting code: %w", err)
+	}
+	return buf.String(), nil
+}
+
+// codePreWrapper keeps the language-* class on <code> for consumers that style by language
+type codePreWrapper struct {
+	lang string
+}
+
+func (p codePreWrapper) Start(code bool, styleAttr string) string {
+	if !code {
+		return fmt.Sprintf(`<pre tabindex="0"%s>`, styleAttr)
+	}
+	if p.lang == "" {
+		return fmt.Sprintf(`<pre tabindex="0"%s><code>`, styleAttr)
+	}
+	return fmt.Sprintf(`<pre tabindex="0"%s><code class="language-%s">`, styleAttr, p.lang)
+}
+
+func (p codePreWrapper) End(code bool) string {
+	if code {
+		return "</code></pre>"
+	}
+	return "</pre>"
+}
+
+// WriteHighlightCSS writes the stylesheet for highlighted code blocks.
+// The light theme applies by default; the dark theme applies below a .dark
+// ancestor or when the reader prefers a dark colour scheme.
+func WriteHighlightCSS(w io.Writer) error {
+	formatter := chromahtml.New(chromahtml.WithClasses(true), chromahtml.WithLineNumbers(true))
+
+	var light, dark bytes.Buffer
+	if err := formatter.WriteCSS(&light, styles.Get(lightHighlightStyle)); err != nil {
+		return fmt.Errorf("writing light theme: %w", err)
+	}
+	if err := formatter.WriteCSS(&dark, styles.Get(darkHighlightStyle)); err != nil {
+		return fmt.Errorf("writing dark theme: %w", err)
+	}
+
+	lightCSS := withoutBackgroundRule(light.String())
+	darkCSS := withoutBackgroundRule(dark.String())
+
+	css := fmt.Sprintf("/* Light theme (%s) */\n%s\n/* Dark theme (%s) */\n%s\n@media (prefers-color-scheme: dark) {\n%s}\n",
+		lightHighlightStyle, lightCSS,
+		darkHighlightStyle, strings.ReplaceAll(darkCSS, ".chroma", ".dark .chroma"),
+		strings.ReplaceAll(darkCSS, ".chroma", ":root:not(.light) .chroma"))
+
+	_, err := io.WriteString(w, css)
+	return err
+}
+
+// withoutBackgroundRule drops chroma's standalone .bg rule, which would leak onto unrelated pages
+func withoutBackgroundRule(css string) string {
+	var lines []string
+	for _, line := range strings.Split(css, "\n") {
+		if !strings.HasPrefix(line, "/* Background */") {
+			lines = append(lines, line)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
@@ -2,19 +2,50 @@ package parser
 
 import (
 	"fmt"
+	"io"
 	"regexp"
 	"strings"
 
 	"github.com/gomarkdown/markdown"
+	"github.com/gomarkdown/markdown/ast"
 	"github.com/gomarkdown/markdown/html"
 	"github.com/gomarkdown/markdown/parser"
+	"prosamik-backend/pkg/models"
 )
 
+// Document is the result of rendering a Markdown document
+type Document struct {
+	HTML    string            // Rendered HTML content
+	TOC     []models.TOCEntry // Nested heading outline of the document
+	Summary Summary           // Excerpt, counts and images for list views
+	Text    string            // Plain text rendering of the document
+}
+
+// Options describes the repository a document belongs to
+type Options struct {
+	Host   string          // Host of the repository, passed to the linker
+	Owner  string          // Owner used for #123 and commit references
+	Repo   string          // Repository used for #123 and commit references
+	Linker ReferenceLinker // Builds reference links; references stay plain text when nil
+
+	// RewriteLink maps the destination of every Markdown link; links are kept as written when nil
+	RewriteLink func(destination string) string
+}
+
 // ConvertMarkdownToHTML converts Markdown to HTML using gomarkdown library
 func ConvertMarkdownToHTML(input string) (string, error) {
+	doc, err := RenderMarkdown(input, Options{})
+	if err != nil {
+		return "", err
+	}
+	return doc.HTML, nil
+}
+
+// RenderMarkdown converts Markdown to HTML and extracts the heading outline from the AST
+func RenderMarkdown(input string, options Options) (*Document, error) {
 	// Validate input
 	if input == "" {
-		return "", fmt.Errorf("empty input")
+		return nil, fmt.Errorf("empty input")
 	}
 
 	// Preprocess the input to handle nested lists and special formatting
@@ -35,16 +66,48 @@ func ConvertMarkdownToHTML(input string) (string, error) {
 	htmlFlags := html.CommonFlags |
 		html.HrefTargetBlank
 
+	// Parse first so GitHub-flavored extensions can rewrite the AST before rendering
+	md := []byte(input)
+	root := markdown.Parse(md, p)
+	rewriteLinks(root, options.RewriteLink)
+	gfm := applyGFM(root, options)
+
 	opts := html.RendererOptions{
 		Flags: htmlFlags,
+		RenderNodeHook: func(w io.Writer, node ast.Node, entering bool) (ast.WalkStatus, bool) {
+			if status, handled := gfm.renderHook(w, node, entering); handled {
+				return status, true
+			}
+			// Server-side syntax highlighting for fenced code
+			return highlightCodeHook(w, node, entering)
+		},
 	}
 	renderer := html.NewRenderer(opts)
 
 	// Convert Markdown to HTML
-	md := []byte(input)
-	htmlContent := markdown.ToHTML(md, p, renderer)
+	htmlContent := markdown.Render(root, renderer)
+
+	// The renderer finalises unique heading ids, so the outline is built after rendering
+	return &Document{
+		HTML:    SanitizeHTML(string(htmlContent)),
+		TOC:     buildTOC(root),
+		Summary: summarize(root),
+		Text:    renderPlainText(root),
+	}, nil
+}
+
+// rewriteLinks applies rewrite to the destination of every link in the document
+func rewriteLinks(root ast.Node, rewrite func(string) string) {
+	if rewrite == nil {
+		return
+	}
 
-	return string(htmlContent), nil
+	ast.WalkFunc(root, func(node ast.Node, entering bool) ast.WalkStatus {
+		if link, ok := node.(*ast.Link); ok && entering {
+			link.Destination = []byte(rewrite(string(link.Destination)))
+		}
+		return ast.GoToNext
+	})
 }
 
 // preprocessMarkdown handles special Markdown formatting cases
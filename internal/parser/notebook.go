@@ -0,0 +1,148 @@
+package parser
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// notebook is the part of the Jupyter nbformat 4 schema that is rendered
+type notebook struct {
+	NBFormat int `json:"nbformat"`
+	Metadata struct {
+		KernelSpec struct {
+			Language string `json:"language"`
+		} `json:"kernelspec"`
+		LanguageInfo struct {
+			Name string `json:"name"`
+		} `json:"language_info"`
+	} `json:"metadata"`
+	Cells []notebookCell `json:"cells"`
+}
+
+// notebookCell is a markdown, code or raw cell
+type notebookCell struct {
+	CellType string           `json:"cell_type"`
+	Source   notebookText     `json:"source"`
+	Outputs  []notebookOutput `json:"outputs"`
+}
+
+// notebookOutput is a stream, result, display or error output of a code cell
+type notebookOutput struct {
+	OutputType string                  `json:"output_type"`
+	Text       notebookText            `json:"text"`
+	Data       map[string]notebookText `json:"data"`
+	EName      string                  `json:"ename"`
+	EValue     string                  `json:"evalue"`
+	Traceback  []string                `json:"traceback"`
+}
+
+// notebookText is multiline text, stored either as a string or as a list of lines
+type notebookText string
+
+func (t *notebookText) UnmarshalJSON(data []byte) error {
+	var lines []string
+	if err := json.Unmarshal(data, &lines); err == nil {
+		*t = notebookText(strings.Join(lines, ""))
+		return nil
+	}
+
+	var text string
+	if err := json.Unmarshal(data, &text); err != nil {
+		return fmt.Errorf("notebook text must be a string or a list of strings")
+	}
+	*t = notebookText(text)
+	return nil
+}
+
+// notebookImageTypes are the image outputs embedded as data URIs, in order of preference
+var notebookImageTypes = []string{"image/png", "image/jpeg", "image/gif"}
+
+// ansiEscapePattern matches the terminal colour codes in tracebacks
+var ansiEscapePattern = regexp.MustCompile("\x1b\\[[0-9;]*[A-Za-z]")
+
+// notebookToMarkdown converts a Jupyter notebook to Markdown. Markdown cells are kept as written,
+// code cells become fenced code in the kernel's language followed by their outputs, and
+// image outputs are embedded as data URIs.
+func notebookToMarkdown(input string) (string, error) {
+	var nb notebook
+	if err := json.Unmarshal([]byte(input), &nb); err != nil {
+		return "", fmt.Errorf("parsing notebook: %w", err)
+	}
+	if nb.NBFormat < 4 {
+		return "", fmt.Errorf("unsupported notebook format version %d", nb.NBFormat)
+	}
+
+	language := nb.Metadata.LanguageInfo.Name
+	if language == "" {
+		language = nb.Metadata.KernelSpec.Language
+	}
+
+	var blocks []string
+	for _, cell := range nb.Cells {
+		source := string(cell.Source)
+		switch cell.CellType {
+		case "markdown":
+			if strings.TrimSpace(source) != "" {
+				blocks = append(blocks, source)
+			}
+		case "code":
+			if strings.TrimSpace(source) != "" {
+				blocks = append(blocks, fencedBlock(language, source))
+			}
+			for _, output := range cell.Outputs {
+				if block := notebookOutputMarkdown(output); block != "" {
+					blocks = append(blocks, block)
+				}
+			}
+		case "raw":
+			if strings.TrimSpace(source) != "" {
+				blocks = append(blocks, fencedBlock("", source))
+			}
+		}
+	}
+
+	if len(blocks) == 0 {
+		return "", fmt.Errorf("empty notebook")
+	}
+	return strings.Join(blocks, "\n\n"), nil
+}
+
+// notebookOutputMarkdown converts one output of a code cell to Markdown, preferring images,
+// then HTML and Markdown, then plain text
+func notebookOutputMarkdown(output notebookOutput) string {
+	switch output.OutputType {
+	case "stream":
+		return notebookTextBlock(string(output.Text))
+	case "error":
+		traceback := strings.Join(output.Traceback, "\n")
+		if traceback == "" {
+			traceback = output.EName + ": " + output.EValue
+		}
+		return notebookTextBlock(ansiEscapePattern.ReplaceAllString(traceback, ""))
+	}
+
+	for _, mediaType := range notebookImageTypes {
+		if data, ok := output.Data[mediaType]; ok {
+			encoded := strings.Join(strings.Fields(string(data)), "")
+			return fmt.Sprintf("![output](data:%s;base64,%s)", mediaType, encoded)
+		}
+	}
+	if data, ok := output.Data["text/html"]; ok {
+		// Raw HTML is sanitized with the rest of the document
+		return strings.TrimSpace(string(data))
+	}
+	if data, ok := output.Data["text/markdown"]; ok {
+		return string(data)
+	}
+	return notebookTextBlock(string(output.Data["text/plain"]))
+}
+
+// notebookTextBlock renders text output as preformatted text
+func notebookTextBlock(text string) string {
+	if strings.TrimSpace(text) == "" {
+		return ""
+	}
+	return fencedBlock("", text)
+}
@@ -0,0 +1,134 @@
+package parser
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/gomarkdown/markdown/ast"
+)
+
+// renderPlainText renders the AST as readable plain text for summarizers and email digests.
+// Blocks are separated by blank lines, links keep their URL and images become their alt text.
+func renderPlainText(root ast.Node) string {
+	var blocks []string
+	for _, child := range root.GetChildren() {
+		if text := plainTextBlock(child, ""); text != "" {
+			blocks = append(blocks, text)
+		}
+	}
+	return strings.Join(blocks, "\n\n") + "\n"
+}
+
+// plainTextBlock renders a block node, prefixing every line with indent
+func plainTextBlock(node ast.Node, indent string) string {
+	switch n := node.(type) {
+	case *ast.Heading:
+		return indent + plainTextInline(n)
+	case *ast.Paragraph:
+		return prefixLines(plainTextInline(n), indent)
+	case *ast.CodeBlock:
+		return prefixLines(strings.TrimRight(string(n.Literal), "\n"), indent+"    ")
+	case *ast.BlockQuote:
+		return plainTextChildren(n, indent+"> ", "\n"+indent+">\n")
+	case *ast.List:
+		var items []string
+		number := n.Start
+		if number == 0 {
+			number = 1
+		}
+		for _, child := range n.GetChildren() {
+			marker := "- "
+			if n.ListFlags&ast.ListTypeOrdered != 0 {
+				marker = strconv.Itoa(number) + ". "
+				number++
+			}
+			item := plainTextChildren(child, indent+strings.Repeat(" ", len(marker)), "\n")
+			items = append(items, indent+marker+strings.TrimPrefix(item, indent+strings.Repeat(" ", len(marker))))
+		}
+		return strings.Join(items, "\n")
+	case *ast.Table:
+		var rows []string
+		ast.WalkFunc(n, func(child ast.Node, entering bool) ast.WalkStatus {
+			row, ok := child.(*ast.TableRow)
+			if !ok || !entering {
+				return ast.GoToNext
+			}
+			var cells []string
+			for _, cell := range row.GetChildren() {
+				cells = append(cells, plainTextInline(cell))
+			}
+			rows = append(rows, indent+strings.Join(cells, " | "))
+			return ast.SkipChildren
+		})
+		return strings.Join(rows, "\n")
+	case *ast.HorizontalRule:
+		return indent + "---"
+	case *ast.HTMLBlock, *ast.HTMLSpan:
+		return ""
+	case *ast.Footnotes:
+		return plainTextChildren(n, indent, "\n")
+	default:
+		if n.AsContainer() != nil {
+			return plainTextChildren(n, indent, "\n\n")
+		}
+		return ""
+	}
+}
+
+// plainTextChildren renders the block children of a container joined by sep
+func plainTextChildren(node ast.Node, indent, sep string) string {
+	var parts []string
+	for _, child := range node.GetChildren() {
+		if text := plainTextBlock(child, indent); text != "" {
+			parts = append(parts, text)
+		}
+	}
+	return strings.Join(parts, sep)
+}
+
+// plainTextInline renders the inline content of a block on as few lines as the source had
+func plainTextInline(node ast.Node) string {
+	var sb strings.Builder
+	ast.WalkFunc(node, func(n ast.Node, entering bool) ast.WalkStatus {
+		switch leaf := n.(type) {
+		case *ast.Text:
+			if entering {
+				sb.Write(leaf.Literal)
+			}
+		case *ast.Code:
+			if entering {
+				sb.Write(leaf.Literal)
+			}
+		case *ast.Softbreak:
+			sb.WriteByte(' ')
+		case *ast.Hardbreak:
+			sb.WriteByte('\n')
+		case *ast.Image:
+			if entering {
+				sb.WriteString(plainText(leaf))
+			}
+			return ast.SkipChildren
+		case *ast.Link:
+			// Keep the URL next to the text unless the text already is the URL
+			if !entering && len(leaf.Destination) > 0 && !strings.HasPrefix(string(leaf.Destination), "#") {
+				if text := plainText(leaf); text != string(leaf.Destination) {
+					sb.WriteString(" (" + string(leaf.Destination) + ")")
+				}
+			}
+		}
+		return ast.GoToNext
+	})
+	return strings.TrimSpace(sb.String())
+}
+
+// prefixLines prefixes every line of text with indent
+func prefixLines(text, indent string) string {
+	if text == "" || indent == "" {
+		return text
+	}
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		lines[i] = indent + line
+	}
+	return strings.Join(lines, "\n")
+}
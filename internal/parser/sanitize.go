@@ -0,0 +1,134 @@
+package parser
+
+import (
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/microcosm-cc/bluemonday"
+)
+
+// SanitizePolicy is the allowlist applied to rendered HTML.
+// Anything not listed here is stripped, including scripts, event handlers and inline styles.
+type SanitizePolicy struct {
+	AllowedTags       []string            // Elements kept in the output
+	AllowedAttributes map[string][]string // Attributes per element; the "*" key applies to every element
+	AllowedURLSchemes []string            // Schemes allowed in href and src; relative URLs are always allowed
+}
+
+// DefaultSanitizePolicy allows the HTML GitHub itself renders in READMEs
+func DefaultSanitizePolicy() SanitizePolicy {
+	return SanitizePolicy{
+		AllowedTags: []string{
+			"a", "abbr", "b", "blockquote", "br", "caption", "code", "dd", "del", "details", "div",
+			"dl", "dt", "em", "figcaption", "figure", "h1", "h2", "h3", "h4", "h5", "h6", "hr", "i",
+			"img", "input", "ins", "kbd", "li", "mark", "ol", "p", "picture", "pre", "q", "rp", "rt", "ruby",
+			"s", "samp", "source", "span", "strike", "strong", "sub", "summary", "sup", "table",
+			"tbody", "td", "tfoot", "th", "thead", "tr", "tt", "u", "ul", "var",
+		},
+		AllowedAttributes: map[string][]string{
+			"*":       {"id", "class", "title", "align", "dir", "lang"},
+			"a":       {"href", "name", "target", "rel"},
+			"img":     {"src", "alt", "width", "height", "srcset", "loading"},
+			"source":  {"srcset", "media", "type", "sizes", "width", "height"},
+			"td":      {"colspan", "rowspan"},
+			"th":      {"colspan", "rowspan", "scope"},
+			"ol":      {"start", "type", "reversed"},
+			"li":      {"value"},
+			"details": {"open"},
+			"pre":     {"tabindex"},
+			"input":   {"type", "checked", "disabled"},
+		},
+		AllowedURLSchemes: []string{"http", "https", "mailto"},
+	}
+}
+
+var (
+	sanitizer   *bluemonday.Policy
+	sanitizerMu sync.Mutex
+)
+
+// SetSanitizePolicy replaces the policy applied to rendered HTML
+func SetSanitizePolicy(policy SanitizePolicy) {
+	sanitizerMu.Lock()
+	defer sanitizerMu.Unlock()
+	sanitizer = buildSanitizer(policy)
+}
+
+// SanitizeHTML strips everything not allowed by the sanitize policy
+func SanitizeHTML(input string) string {
+	return currentSanitizer().Sanitize(input)
+}
+
+// currentSanitizer returns the active policy, building it from the environment on first use
+func currentSanitizer() *bluemonday.Policy {
+	sanitizerMu.Lock()
+	defer sanitizerMu.Unlock()
+	if sanitizer == nil {
+		sanitizer = buildSanitizer(policyFromEnv())
+	}
+	return sanitizer
+}
+
+// policyFromEnv extends the default policy with SANITIZE_ALLOWED_TAGS (comma separated),
+// SANITIZE_ALLOWED_ATTRIBUTES ("tag:attr|attr,*:attr") and replaces the schemes with
+// SANITIZE_URL_SCHEMES when it is set
+func policyFromEnv() SanitizePolicy {
+	policy := DefaultSanitizePolicy()
+
+	for _, tag := range splitList(os.Getenv("SANITIZE_ALLOWED_TAGS"), ",") {
+		policy.AllowedTags = append(policy.AllowedTags, strings.ToLower(tag))
+	}
+
+	for _, entry := range splitList(os.Getenv("SANITIZE_ALLOWED_ATTRIBUTES"), ",") {
+		tag, attrs, ok := strings.Cut(entry, ":")
+		if !ok {
+			continue
+		}
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		policy.AllowedAttributes[tag] = append(policy.AllowedAttributes[tag], splitList(attrs, "|")...)
+	}
+
+	if schemes := splitList(os.Getenv("SANITIZE_URL_SCHEMES"), ","); len(schemes) > 0 {
+		policy.AllowedURLSchemes = schemes
+	}
+
+	return policy
+}
+
+// buildSanitizer turns a policy into a bluemonday policy
+func buildSanitizer(policy SanitizePolicy) *bluemonday.Policy {
+	p := bluemonday.NewPolicy()
+	p.AllowElements(policy.AllowedTags...)
+
+	for tag, attrs := range policy.AllowedAttributes {
+		if len(attrs) == 0 {
+			continue
+		}
+		if tag == "*" {
+			p.AllowAttrs(attrs...).Globally()
+		} else {
+			p.AllowAttrs(attrs...).OnElements(tag)
+		}
+	}
+
+	p.RequireParseableURLs(true)
+	p.AllowRelativeURLs(true)
+	p.AllowURLSchemes(policy.AllowedURLSchemes...)
+	// Notebook outputs embed their images; bluemonday only lets base64 raster images through
+	p.AllowDataURIImages()
+	p.RequireNoFollowOnLinks(false)
+
+	return p
+}
+
+// splitList splits a separated list and drops empty items
+func splitList(value, sep string) []string {
+	var items []string
+	for _, item := range strings.Split(value, sep) {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
@@ -0,0 +1,68 @@
+package parser
+
+import (
+	"fmt"
+	"path"
+	"strings"
+)
+
+// SourceFormat is the markup language of a document file
+type SourceFormat string
+
+const (
+	SourceMarkdown  SourceFormat = "markdown"  // Markdown, the default for unknown extensions
+	SourceNotebook  SourceFormat = "notebook"  // Jupyter notebook
+	SourcePlainText SourceFormat = "plaintext" // Text rendered as written
+)
+
+// sourceExtensions maps file extensions to the formats they are rendered as.
+// AsciiDoc and reStructuredText have no converter yet and are shown as plain text.
+var sourceExtensions = map[string]SourceFormat{
+	".ipynb":    SourceNotebook,
+	".txt":      SourcePlainText,
+	".text":     SourcePlainText,
+	".log":      SourcePlainText,
+	".adoc":     SourcePlainText,
+	".asciidoc": SourcePlainText,
+	".rst":      SourcePlainText,
+}
+
+// DetectSourceFormat picks the format of a document from its file extension
+func DetectSourceFormat(filePath string) SourceFormat {
+	if format, ok := sourceExtensions[strings.ToLower(path.Ext(filePath))]; ok {
+		return format
+	}
+	return SourceMarkdown
+}
+
+// ToMarkdown converts a document to the Markdown rendered by RenderMarkdown.
+// Markdown is returned unchanged.
+func ToMarkdown(format SourceFormat, input string) (string, error) {
+	switch format {
+	case SourceNotebook:
+		return notebookToMarkdown(input)
+	case SourcePlainText:
+		return fencedBlock("text", input), nil
+	case SourceMarkdown:
+		return input, nil
+	default:
+		return "", fmt.Errorf("unsupported source format: %s", format)
+	}
+}
+
+// fencedBlock wraps text in a fenced code block with a fence longer than any backtick run inside it
+func fencedBlock(info, text string) string {
+	longest, run := 0, 0
+	for _, c := range text {
+		if c == '`' {
+			run++
+			longest = max(longest, run)
+		} else {
+			run = 0
+		}
+	}
+	fence := strings.Repeat("`", max(3, longest+1))
+
+	text = strings.TrimRight(strings.ReplaceAll(text, "\r\n", "\n"), "\n")
+	return fence + info + "\n" + text + "\n" + fence + "\n"
+}
@@ -0,0 +1,119 @@
+package parser
+
+import (
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/gomarkdown/markdown/ast"
+)
+
+const (
+	// excerptLength is the maximum length of an excerpt in runes
+	excerptLength = 160
+	// wordsPerMinute is the reading speed used for the reading time estimate
+	wordsPerMinute = 200
+)
+
+// htmlImagePattern matches the src of <img> tags in raw HTML
+var htmlImagePattern = regexp.MustCompile(`(?i)<img\b[^>]*?\bsrc\s*=\s*["']([^"']+)["']`)
+
+// Summary describes a document for list views and social cards
+type Summary struct {
+	Excerpt      string   // Plain text of the first real paragraph
+	WordCount    int      // Words of prose, excluding code blocks
+	ReadingTime  int      // Estimated reading time in minutes
+	HeadingCount int      // Number of headings
+	Images       []string // Image URLs in document order, without duplicates
+}
+
+// summarize walks the AST and collects the summary of the document
+func summarize(root ast.Node) Summary {
+	var summary Summary
+	seenImages := make(map[string]bool)
+	addImage := func(src string) {
+		// Embedded data URIs are too large for metadata
+		if src != "" && !strings.HasPrefix(src, "data:") && !seenImages[src] {
+			seenImages[src] = true
+			summary.Images = append(summary.Images, src)
+		}
+	}
+
+	ast.WalkFunc(root, func(node ast.Node, entering bool) ast.WalkStatus {
+		if !entering {
+			return ast.GoToNext
+		}
+
+		switch n := node.(type) {
+		case *ast.Heading:
+			if !n.IsTitleblock {
+				summary.HeadingCount++
+			}
+		case *ast.Paragraph:
+			if summary.Excerpt == "" {
+				if _, topLevel := n.GetParent().(*ast.Document); topLevel {
+					summary.Excerpt = truncateText(proseText(n), excerptLength)
+				}
+			}
+		case *ast.Image:
+			addImage(string(n.Destination))
+			// Alt text is not prose
+			return ast.SkipChildren
+		case *ast.HTMLBlock:
+			for _, match := range htmlImagePattern.FindAllSubmatch(n.Literal, -1) {
+				addImage(string(match[1]))
+			}
+		case *ast.HTMLSpan:
+			for _, match := range htmlImagePattern.FindAllSubmatch(n.Literal, -1) {
+				addImage(string(match[1]))
+			}
+		case *ast.Text:
+			summary.WordCount += len(strings.Fields(string(n.Literal)))
+		case *ast.Code:
+			summary.WordCount += len(strings.Fields(string(n.Literal)))
+		}
+		return ast.GoToNext
+	})
+
+	if summary.WordCount > 0 {
+		summary.ReadingTime = (summary.WordCount + wordsPerMinute - 1) / wordsPerMinute
+	}
+
+	return summary
+}
+
+// proseText returns the plain text of a block without image alt text, collapsing whitespace
+func proseText(node ast.Node) string {
+	var sb strings.Builder
+	ast.WalkFunc(node, func(n ast.Node, entering bool) ast.WalkStatus {
+		if !entering {
+			return ast.GoToNext
+		}
+		switch leaf := n.(type) {
+		case *ast.Image:
+			return ast.SkipChildren
+		case *ast.Text:
+			sb.Write(leaf.Literal)
+		case *ast.Code:
+			sb.Write(leaf.Literal)
+		case *ast.Softbreak, *ast.Hardbreak:
+			sb.WriteByte(' ')
+		}
+		return ast.GoToNext
+	})
+	return strings.Join(strings.Fields(sb.String()), " ")
+}
+
+// truncateText shortens text to at most limit runes, cutting at the last complete word
+func truncateText(text string, limit int) string {
+	if utf8.RuneCountInString(text) <= limit {
+		return text
+	}
+
+	runes := []rune(text)
+	cut := string(runes[:limit])
+	if lastSpace := strings.LastIndex(cut, " "); lastSpace > 0 {
+		cut = cut[:lastSpace]
+	}
+	return strings.TrimRight(cut, " ,;:.-") + "..."
+}
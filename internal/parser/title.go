@@ -0,0 +1,39 @@
+package parser
+
+import (
+	"strings"
+
+	"github.com/gomarkdown/markdown"
+	"github.com/gomarkdown/markdown/ast"
+	"github.com/gomarkdown/markdown/parser"
+)
+
+// DocumentTitle returns the title of a document file from its front matter or its first heading,
+// or an empty string when it has neither
+func DocumentTitle(filePath, input string) string {
+	if format := DetectSourceFormat(filePath); format != SourceMarkdown {
+		converted, err := ToMarkdown(format, input)
+		if err != nil {
+			return ""
+		}
+		input = converted
+	} else if fm, body, err := ExtractFrontMatter(input); err == nil {
+		if fm != nil && fm.Title != "" {
+			return fm.Title
+		}
+		input = body
+	}
+
+	root := markdown.Parse([]byte(input), parser.NewWithExtensions(parser.CommonExtensions))
+
+	var title string
+	ast.WalkFunc(root, func(node ast.Node, entering bool) ast.WalkStatus {
+		heading, ok := node.(*ast.Heading)
+		if !ok || !entering || heading.IsTitleblock {
+			return ast.GoToNext
+		}
+		title = strings.TrimSpace(plainText(heading))
+		return ast.Terminate
+	})
+	return title
+}
@@ -0,0 +1,76 @@
+package parser
+
+import (
+	"strings"
+
+	"github.com/gomarkdown/markdown/ast"
+	"prosamik-backend/pkg/models"
+)
+
+// buildTOC walks the AST and nests every heading under the closest preceding heading of a lower level
+func buildTOC(root ast.Node) []models.TOCEntry {
+	var toc []models.TOCEntry
+	// stack holds the path of open headings from the top level down
+	var stack []*models.TOCEntry
+
+	ast.WalkFunc(root, func(node ast.Node, entering bool) ast.WalkStatus {
+		heading, ok := node.(*ast.Heading)
+		if !ok || !entering || heading.IsTitleblock {
+			return ast.GoToNext
+		}
+
+		entry := models.TOCEntry{
+			Level: heading.Level,
+			Text:  strings.TrimSpace(plainText(heading)),
+			ID:    heading.HeadingID,
+		}
+
+		for len(stack) > 0 && stack[len(stack)-1].Level >= entry.Level {
+			stack = stack[:len(stack)-1]
+		}
+
+		if len(stack) == 0 {
+			toc = append(toc, entry)
+			stack = append(stack, &toc[len(toc)-1])
+		} else {
+			parent := stack[len(stack)-1]
+			parent.Children = append(parent.Children, entry)
+			stack = append(stack, &parent.Children[len(parent.Children)-1])
+		}
+
+		return ast.SkipChildren
+	})
+
+	return toc
+}
+
+// LimitTOCDepth returns the outline without headings deeper than maxLevel
+func LimitTOCDepth(toc []models.TOCEntry, maxLevel int) []models.TOCEntry {
+	var limited []models.TOCEntry
+	for _, entry := range toc {
+		if entry.Level > maxLevel {
+			continue
+		}
+		entry.Children = LimitTOCDepth(entry.Children, maxLevel)
+		limited = append(limited, entry)
+	}
+	return limited
+}
+
+// plainText concatenates the literal text of all leaf nodes below node
+func plainText(node ast.Node) string {
+	var sb strings.Builder
+	ast.WalkFunc(node, func(n ast.Node, entering bool) ast.WalkStatus {
+		if !entering {
+			return ast.GoToNext
+		}
+		switch leaf := n.(type) {
+		case *ast.Text:
+			sb.Write(leaf.Literal)
+		case *ast.Code:
+			sb.Write(leaf.Literal)
+		}
+		return ast.GoToNext
+	})
+	return sb.String()
+}
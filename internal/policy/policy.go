@@ -0,0 +1,174 @@
+package policy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"prosamik-backend/internal/fetcher"
+	"prosamik-backend/internal/repository"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Mode selects which repositories the public document endpoints read from
+type Mode string
+
+const (
+	ModeOpen       Mode = "open"       // Any public repository
+	ModeAllowlist  Mode = "allowlist"  // MD_ALLOWED_OWNERS, MD_ALLOWED_REPOS and registered repositories
+	ModeRegistered Mode = "registered" // Only repositories of registered blogs and projects
+)
+
+// registeredTTL is how long the registered blog and project paths are reused before they are read again
+const registeredTTL = time.Minute
+
+// ErrDenied is wrapped by the errors of documents the policy refuses
+var ErrDenied = errors.New("document not allowed")
+
+// config is the policy read from the environment
+type config struct {
+	mode   Mode
+	owners map[string]bool // Lowercased owners
+	repos  map[string]bool // Lowercased owner/repo pairs
+}
+
+// registered indexes the documents of registered blogs and projects
+type registered struct {
+	repositories map[string]bool // Repository keys
+	documents    map[string]bool // Repository keys with the document path
+	loadedAt     time.Time
+}
+
+var (
+	cfg     config
+	cfgOnce sync.Once
+
+	registeredMu    sync.Mutex
+	registeredPaths *registered
+)
+
+// currentConfig reads MD_POLICY_MODE (open by default), MD_ALLOWED_OWNERS and MD_ALLOWED_REPOS on first use
+func currentConfig() config {
+	cfgOnce.Do(func() {
+		cfg = config{mode: ModeOpen, owners: make(map[string]bool), repos: make(map[string]bool)}
+
+		switch mode := Mode(strings.ToLower(strings.TrimSpace(os.Getenv("MD_POLICY_MODE")))); mode {
+		case "":
+		case ModeOpen, ModeAllowlist, ModeRegistered:
+			cfg.mode = mode
+		default:
+			// An unknown mode must not silently open the endpoints up
+			fmt.Printf("Warning: unknown MD_POLICY_MODE %q, using %s\n", mode, ModeRegistered)
+			cfg.mode = ModeRegistered
+		}
+
+		for _, owner := range strings.Split(os.Getenv("MD_ALLOWED_OWNERS"), ",") {
+			if owner = strings.ToLower(strings.TrimSpace(owner)); owner != "" {
+				cfg.owners[owner] = true
+			}
+		}
+		for _, repo := range strings.Split(os.Getenv("MD_ALLOWED_REPOS"), ",") {
+			if repo = strings.ToLower(strings.Trim(strings.TrimSpace(repo), "/")); repo != "" {
+				cfg.repos[repo] = true
+			}
+		}
+	})
+	return cfg
+}
+
+// CurrentMode returns the configured policy mode
+func CurrentMode() Mode {
+	return currentConfig().mode
+}
+
+// CheckDocument decides whether a public endpoint may read a document. Local documents are
+// always allowed. Registered blog and project documents are always allowed, other repositories
+// depend on the mode, and private repositories are refused unless the document itself is registered.
+func CheckDocument(ctx context.Context, source fetcher.ContentSource, ref *fetcher.DocumentRef) error {
+	return check(ctx, source, ref, false)
+}
+
+// CheckRawFile decides whether the image proxy may serve a repository file. It follows CheckDocument,
+// except that any file of a repository with a registered document is allowed, private or not,
+// since the images of registered documents live next to them.
+func CheckRawFile(ctx context.Context, source fetcher.ContentSource, ref *fetcher.DocumentRef) error {
+	return check(ctx, source, ref, true)
+}
+
+// check applies the policy to a document, or to a raw file of a repository when rawFile is set
+func check(ctx context.Context, source fetcher.ContentSource, ref *fetcher.DocumentRef, rawFile bool) error {
+	if source.Name() == "local" {
+		return nil
+	}
+
+	reg := currentRegistered()
+	repository := ref.RepositoryKey()
+	if reg.documents[documentKey(ref)] || rawFile && reg.repositories[repository] {
+		return nil
+	}
+
+	c := currentConfig()
+	switch c.mode {
+	case ModeAllowlist:
+		if !reg.repositories[repository] && !c.owners[strings.ToLower(ref.Owner)] &&
+			!c.repos[strings.ToLower(ref.Owner+"/"+ref.Repo)] {
+			return fmt.Errorf("%w: repository %s/%s is not on the allowlist", ErrDenied, ref.Owner, ref.Repo)
+		}
+	case ModeRegistered:
+		if !reg.repositories[repository] {
+			return fmt.Errorf("%w: repository %s/%s is not registered", ErrDenied, ref.Owner, ref.Repo)
+		}
+	}
+
+	private, err := fetcher.RepositoryPrivate(ctx, source, ref)
+	if err != nil {
+		return fmt.Errorf("checking repository visibility: %w", err)
+	}
+	if private {
+		return fmt.Errorf("%w: %s/%s is a private repository", ErrDenied, ref.Owner, ref.Repo)
+	}
+	return nil
+}
+
+// documentKey identifies a document of a repository regardless of the branch it is read from
+func documentKey(ref *fetcher.DocumentRef) string {
+	return ref.RepositoryKey() + ":" + ref.Path
+}
+
+// currentRegistered returns the registered documents, reading them again once registeredTTL has passed.
+// When the database cannot be read the previous paths are kept, or none at all on the first read.
+func currentRegistered() *registered {
+	registeredMu.Lock()
+	defer registeredMu.Unlock()
+
+	if registeredPaths != nil && time.Since(registeredPaths.loadedAt) < registeredTTL {
+		return registeredPaths
+	}
+
+	urls, err := repository.RegisteredPaths()
+	if err != nil {
+		fmt.Printf("Warning: failed to read registered paths for the document policy: %v\n", err)
+		if registeredPaths == nil {
+			return &registered{}
+		}
+		return registeredPaths
+	}
+
+	reg := &registered{
+		repositories: make(map[string]bool),
+		documents:    make(map[string]bool),
+		loadedAt:     time.Now(),
+	}
+	for _, url := range urls {
+		_, ref, err := fetcher.ResolveSource(url)
+		if err != nil {
+			continue
+		}
+		reg.repositories[ref.RepositoryKey()] = true
+		reg.documents[documentKey(ref)] = true
+	}
+	registeredPaths = reg
+	return reg
+}
@@ -0,0 +1,155 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"prosamik-backend/internal/database"
+	"prosamik-backend/pkg/models"
+	"strings"
+	"time"
+)
+
+type APIKeyRepository struct {
+	db *sql.DB
+}
+
+func NewAPIKeyRepository() *APIKeyRepository {
+	return &APIKeyRepository{
+		db: database.DB,
+	}
+}
+
+// CreateAPIKey stores a new key by its hash and fills in its ID and creation time
+func (r *APIKeyRepository) CreateAPIKey(key *models.APIKey, hash string) error {
+	query := `
+        INSERT INTO api_keys (name, prefix, key_hash, daily_quota, rate_limit)
+        VALUES ($1, $2, $3, $4, $5)
+        RETURNING id, created_at
+    `
+
+	err := r.db.QueryRow(query, strings.TrimSpace(key.Name), key.Prefix, hash, key.DailyQuota, key.RateLimit).
+		Scan(&key.ID, &key.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("create API key error: %w", err)
+	}
+	return nil
+}
+
+// GetAPIKeyByHash returns the key with the given hash, or nil when there is none
+func (r *APIKeyRepository) GetAPIKeyByHash(hash string) (*models.APIKey, error) {
+	query := `
+        SELECT id, name, prefix, daily_quota, rate_limit, created_at, last_used_at, revoked_at
+        FROM api_keys
+        WHERE key_hash = $1
+    `
+
+	key := &models.APIKey{}
+	err := r.db.QueryRow(query, hash).Scan(
+		&key.ID,
+		&key.Name,
+		&key.Prefix,
+		&key.DailyQuota,
+		&key.RateLimit,
+		&key.CreatedAt,
+		&key.LastUsedAt,
+		&key.RevokedAt,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("scan error: %w", err)
+	}
+	return key, nil
+}
+
+// GetAllAPIKeys returns every key, newest first, with its requests of today and the last seven days
+func (r *APIKeyRepository) GetAllAPIKeys(today time.Time) ([]*models.APIKey, error) {
+	query := `
+        SELECT k.id, k.name, k.prefix, k.daily_quota, k.rate_limit, k.created_at, k.last_used_at, k.revoked_at,
+               COALESCE(SUM(u.requests) FILTER (WHERE u.date = $1), 0),
+               COALESCE(SUM(u.requests), 0)
+        FROM api_keys k
+        LEFT JOIN api_key_usage u ON u.api_key_id = k.id AND u.date > $1::date - 7
+        GROUP BY k.id
+        ORDER BY k.id DESC
+    `
+
+	rows, err := r.db.Query(query, today.Format("2006-01-02"))
+	if err != nil {
+		return nil, fmt.Errorf("query error: %w", err)
+	}
+	defer func() {
+		if cerr := rows.Close(); cerr != nil {
+			fmt.Printf("Warning: failed to close rows: %v\n", cerr)
+		}
+	}()
+
+	var keys []*models.APIKey
+	for rows.Next() {
+		key := &models.APIKey{}
+		if err := rows.Scan(
+			&key.ID,
+			&key.Name,
+			&key.Prefix,
+			&key.DailyQuota,
+			&key.RateLimit,
+			&key.CreatedAt,
+			&key.LastUsedAt,
+			&key.RevokedAt,
+			&key.RequestsToday,
+			&key.RequestsWeek,
+		); err != nil {
+			return nil, fmt.Errorf("scan error: %w", err)
+		}
+		keys = append(keys, key)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+	return keys, nil
+}
+
+// RevokeAPIKey marks a key as revoked; its usage is kept for the dashboard
+func (r *APIKeyRepository) RevokeAPIKey(id int64) error {
+	query := `
+        UPDATE api_keys
+        SET revoked_at = CURRENT_TIMESTAMP
+        WHERE id = $1 AND revoked_at IS NULL
+    `
+
+	result, err := r.db.Exec(query, id)
+	if err != nil {
+		return fmt.Errorf("revoke error: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("rows affected error: %w", err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("no active API key found with id: %d", id)
+	}
+	return nil
+}
+
+// IncrementUsage counts requests of the key on the given day and returns the requests of that day
+func (r *APIKeyRepository) IncrementUsage(id int64, day time.Time, requests int) (int, error) {
+	query := `
+        WITH touched AS (
+            UPDATE api_keys SET last_used_at = CURRENT_TIMESTAMP WHERE id = $1
+        )
+        INSERT INTO api_key_usage (api_key_id, date, requests)
+        VALUES ($1, $2, $3)
+        ON CONFLICT (api_key_id, date)
+        DO UPDATE SET requests = api_key_usage.requests + $3
+        RETURNING requests
+    `
+
+	var total int
+	if err := r.db.QueryRow(query, id, day.Format("2006-01-02"), requests).Scan(&total); err != nil {
+		return 0, fmt.Errorf("increment usage error: %w", err)
+	}
+	return total, nil
+}
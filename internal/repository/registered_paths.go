@@ -0,0 +1,35 @@
+package repository
+
+import (
+	"fmt"
+	"strings"
+)
+
+// RegisteredPaths returns the distinct document URLs of all blogs and projects
+func RegisteredPaths() ([]string, error) {
+	blogs, err := NewBlogRepository().GetAllBlogs()
+	if err != nil {
+		return nil, fmt.Errorf("listing blogs: %w", err)
+	}
+	projects, err := NewProjectRepository().GetAllProjects()
+	if err != nil {
+		return nil, fmt.Errorf("listing projects: %w", err)
+	}
+
+	seen := make(map[string]bool)
+	var urls []string
+	add := func(path string) {
+		path = strings.TrimSpace(path)
+		if path != "" && !seen[path] {
+			seen[path] = true
+			urls = append(urls, path)
+		}
+	}
+	for _, blog := range blogs {
+		add(blog.Path)
+	}
+	for _, project := range projects {
+		add(project.Path)
+	}
+	return urls, nil
+}
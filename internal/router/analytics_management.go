@@ -16,9 +16,10 @@ func RegisterAnalyticsManagementRoutes() {
 	}
 
 	routes := map[string]http.HandlerFunc{
-		"/analytics/management": handler.HandleAnalyticsManagement,
-		"/analytics/filter":     handler.HandleAnalyticsFilter,
-		"/analytics/cache":      handler.HandleCacheMonitoring,
+		"/analytics/management":   handler.HandleAnalyticsManagement,
+		"/analytics/filter":       handler.HandleAnalyticsFilter,
+		"/analytics/cache":        handler.HandleCacheMonitoring,
+		"/analytics/cache/warmer": handler.HandleCacheWarmer,
 	}
 
 	for path, handlers := range routes {
@@ -0,0 +1,28 @@
+package router
+
+import (
+	"net/http"
+	"prosamik-backend/internal/handler"
+	"prosamik-backend/internal/middleware"
+)
+
+func RegisterAPIKeyManagementRoutes() {
+	withMiddlewares := func(h http.HandlerFunc) http.HandlerFunc {
+		return middleware.CORSMiddleware(
+			middleware.LoggingMiddleware(
+				middleware.AuthMiddleware(h),
+			),
+		)
+	}
+
+	routes := map[string]http.HandlerFunc{
+		"/api-keys/management": handler.HandleAPIKeyManagement,
+		"/api-keys/table":      handler.HandleAPIKeyTable,
+		"/api-keys/create":     handler.HandleAPIKeyCreate,
+		"/api-keys/revoke/":    handler.HandleAPIKeyRevoke,
+	}
+
+	for path, handlers := range routes {
+		http.HandleFunc(path, withMiddlewares(handlers))
+	}
+}
@@ -12,6 +12,10 @@ func RegisterAPIRoutes() {
 	// Reason: Initialize rate limiter once to be used across multiple routes
 	rateLimiter := middleware.NewRateLimiter(60, time.Minute)
 
+	// Reason: Read the document limits at startup so a missing TRUSTED_PROXIES is reported before traffic arrives
+	middleware.DocumentLimiter.Init()
+	middleware.ImageLimiter.Init()
+
 	// Helper function for standard middleware chain
 	// Reason: Creates a reusable middleware stack for regular routes
 	withStandardMiddlewares := func(h http.HandlerFunc) http.HandlerFunc {
@@ -30,14 +34,35 @@ func RegisterAPIRoutes() {
 		)
 	}
 
+	// Helper function for the document middleware chain
+	// Reason: Documents spend the upstream API quota, so they are limited per API key,
+	// with a stricter tier for anonymous clients
+	withMarkdownMiddlewares := func(h http.HandlerFunc) http.HandlerFunc {
+		return middleware.CORSMiddleware(
+			middleware.LoggingMiddleware(
+				middleware.DocumentLimiter.Middleware(h),
+			),
+		)
+	}
+
 	// Standard routes without rate limiting
 	// Reason: Group similar routes together for better organization
 	standardRoutes := map[string]http.HandlerFunc{
 		"/blogs":                 handler.HandleBlogsList,
 		"/projects":              handler.HandleProjectsList,
-		"/md":                    handler.MarkdownHandler,
+		"/webhooks/github":       handler.HandleGitHubWebhook,
 		"/analytics":             handler.HandleAnalytics,
-		"/analytics/cache/stats": handler.HandleCacheStats, // API endpoint
+		"/analytics/cache/stats": handler.HandleCacheStats,  // API endpoint
+		"/img":                   handler.ImageProxyHandler, // Charges its own limiter on cache misses
+	}
+
+	// Document routes, limited per API key or anonymous client
+	// Reason: They are public and fetch from the content sources with our credentials
+	markdownRoutes := map[string]http.HandlerFunc{
+		"/md":       handler.MarkdownHandler,
+		"/md/batch": handler.HandleMarkdownBatch,
+		"/md/diff":  handler.HandleMarkdownDiff,
+		"/docs":     handler.HandleDocs,
 	}
 
 	// Rate-limited routes
@@ -53,6 +78,11 @@ func RegisterAPIRoutes() {
 		http.HandleFunc(path, withStandardMiddlewares(apiHandlers))
 	}
 
+	// Register document routes
+	for path, handlers := range markdownRoutes {
+		http.HandleFunc(path, withMarkdownMiddlewares(handlers))
+	}
+
 	// Register rate-limited routes
 	// Reason: Apply rate-limited middleware stack to routes that need it
 	for path, handlers := range rateLimitedRoutes {
@@ -0,0 +1,43 @@
+package router
+
+import (
+	"net/http"
+	"prosamik-backend/internal/handler"
+	"prosamik-backend/internal/middleware"
+)
+
+func RegisterMarkdownRoutes() {
+	// API routes authenticated with a bearer token or the dashboard cookie
+	withAPIAuthMiddlewares := func(h http.HandlerFunc) http.HandlerFunc {
+		return middleware.CORSMiddleware(
+			middleware.LoggingMiddleware(
+				middleware.APIAuthMiddleware(h),
+			),
+		)
+	}
+
+	// Dashboard pages redirecting to the login page
+	withDashboardMiddlewares := func(h http.HandlerFunc) http.HandlerFunc {
+		return middleware.CORSMiddleware(
+			middleware.LoggingMiddleware(
+				middleware.AuthMiddleware(h),
+			),
+		)
+	}
+
+	apiRoutes := map[string]http.HandlerFunc{
+		"/md/render": handler.HandleMarkdownRender,
+	}
+
+	dashboardRoutes := map[string]http.HandlerFunc{
+		"/md/preview": handler.HandleMarkdownPreview,
+	}
+
+	for path, handlers := range apiRoutes {
+		http.HandleFunc(path, withAPIAuthMiddlewares(handlers))
+	}
+
+	for path, handlers := range dashboardRoutes {
+		http.HandleFunc(path, withDashboardMiddlewares(handlers))
+	}
+}
@@ -19,4 +19,10 @@ func SetupRoutes() {
 
 	// Register Analytics Management routes
 	RegisterAnalyticsManagementRoutes()
+
+	// Register Markdown tooling routes
+	RegisterMarkdownRoutes()
+
+	// Register API Key Management routes
+	RegisterAPIKeyManagementRoutes()
 }
@@ -0,0 +1,259 @@
+package warmer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"prosamik-backend/internal/fetcher"
+	"prosamik-backend/internal/repository"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	defaultInterval    = 1 * time.Hour
+	defaultConcurrency = 4
+	documentTimeout    = 30 * time.Second
+	maxFailures        = 20 // Failures kept in the status of a run
+)
+
+// WarmFunc renders a document URL through /md and caches it
+type WarmFunc func(ctx context.Context, url string) error
+
+// Failure is a document that could not be warmed
+type Failure struct {
+	URL   string
+	Error string
+}
+
+// Status describes the current or last warmer run
+type Status struct {
+	Enabled     bool          // Whether runs are scheduled
+	Interval    time.Duration // Time between scheduled runs
+	Concurrency int           // Documents warmed at the same time
+	Running     bool
+	Trigger     string // "schedule" or "manual"
+	StartedAt   time.Time
+	FinishedAt  time.Time
+	NextRun     time.Time
+	Total       int
+	Succeeded   int
+	Failed      int
+	Skipped     int       // Documents not attempted because the run stopped early
+	PausedUntil time.Time // Set when the run stopped for the GitHub rate limit
+	Failures    []Failure
+}
+
+var (
+	mu     sync.Mutex
+	status Status
+	warmFn WarmFunc
+)
+
+// Start schedules warmer runs every WARMER_INTERVAL (1h by default, "off" disables them)
+// with WARMER_CONCURRENCY documents at a time. Manual runs work even when scheduling is off.
+func Start(warm WarmFunc) {
+	mu.Lock()
+	warmFn = warm
+	status.Concurrency = concurrency()
+	interval, enabled := interval()
+	status.Enabled = enabled
+	status.Interval = interval
+	mu.Unlock()
+
+	if !enabled {
+		fmt.Println("Cache warmer scheduling is disabled")
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			mu.Lock()
+			status.NextRun = time.Now().Add(interval)
+			mu.Unlock()
+
+			run("schedule")
+			<-ticker.C
+		}
+	}()
+}
+
+// Trigger starts a run in the background and reports false when one is already running
+func Trigger() bool {
+	mu.Lock()
+	ready := warmFn != nil && !status.Running
+	mu.Unlock()
+
+	if !ready {
+		return false
+	}
+	go run("manual")
+	return true
+}
+
+// CurrentStatus returns a snapshot of the warmer status
+func CurrentStatus() Status {
+	mu.Lock()
+	defer mu.Unlock()
+
+	snapshot := status
+	snapshot.Failures = append([]Failure(nil), status.Failures...)
+	return snapshot
+}
+
+// interval reads WARMER_INTERVAL
+func interval() (time.Duration, bool) {
+	value := os.Getenv("WARMER_INTERVAL")
+	if value == "" {
+		return defaultInterval, true
+	}
+	if value == "off" || value == "0" {
+		return 0, false
+	}
+	parsed, err := time.ParseDuration(value)
+	if err != nil || parsed < time.Minute {
+		fmt.Printf("Warning: invalid WARMER_INTERVAL %q, using %s\n", value, defaultInterval)
+		return defaultInterval, true
+	}
+	return parsed, true
+}
+
+// concurrency reads WARMER_CONCURRENCY
+func concurrency() int {
+	if value := os.Getenv("WARMER_CONCURRENCY"); value != "" {
+		if parsed, err := strconv.Atoi(value); err == nil && parsed > 0 {
+			return parsed
+		}
+		fmt.Printf("Warning: invalid WARMER_CONCURRENCY %q, using %d\n", value, defaultConcurrency)
+	}
+	return defaultConcurrency
+}
+
+// run warms every registered document once; it returns immediately when a run is in progress
+func run(trigger string) {
+	mu.Lock()
+	if status.Running || warmFn == nil {
+		mu.Unlock()
+		return
+	}
+	warm := warmFn
+	workers := status.Concurrency
+	status.Running = true
+	status.Trigger = trigger
+	status.StartedAt = time.Now()
+	status.Total, status.Succeeded, status.Failed, status.Skipped = 0, 0, 0, 0
+	status.PausedUntil = time.Time{}
+	status.Failures = nil
+	mu.Unlock()
+
+	defer func() {
+		mu.Lock()
+		status.Running = false
+		status.FinishedAt = time.Now()
+		mu.Unlock()
+	}()
+
+	urls, err := repository.RegisteredPaths()
+	if err != nil {
+		fmt.Printf("Error listing documents to warm: %v\n", err)
+		recordFailure("", err)
+		return
+	}
+
+	mu.Lock()
+	status.Total = len(urls)
+	mu.Unlock()
+
+	warmAll(warm, urls, workers)
+
+	current := CurrentStatus()
+	fmt.Printf("Cache warmer finished: %d warmed, %d failed, %d skipped\n",
+		current.Succeeded, current.Failed, current.Skipped)
+}
+
+// warmAll warms urls with the given number of workers, recording the outcome in the status
+func warmAll(warm WarmFunc, urls []string, workers int) {
+	// The run stops at the first rate-limit error instead of failing every remaining document
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	jobs := make(chan string)
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for url := range jobs {
+				if ctx.Err() != nil {
+					recordSkipped()
+					continue
+				}
+				if err := warmDocument(ctx, warm, url); err != nil {
+					var rateLimitErr *fetcher.RateLimitError
+					if errors.As(err, &rateLimitErr) {
+						pause(rateLimitErr.RetryAt)
+						cancel()
+						recordSkipped()
+						continue
+					}
+					recordFailure(url, err)
+					continue
+				}
+				recordSuccess()
+			}
+		}()
+	}
+
+	for _, url := range urls {
+		jobs <- url
+	}
+	close(jobs)
+	wg.Wait()
+}
+
+// warmDocument warms one document, pausing first when the GitHub API quota is down to its reserve
+func warmDocument(ctx context.Context, warm WarmFunc, url string) error {
+	if err := fetcher.CheckGitHubRateLimit(); err != nil {
+		return err
+	}
+
+	docCtx, cancel := context.WithTimeout(ctx, documentTimeout)
+	defer cancel()
+	return warm(docCtx, url)
+}
+
+func recordSuccess() {
+	mu.Lock()
+	defer mu.Unlock()
+	status.Succeeded++
+}
+
+func recordSkipped() {
+	mu.Lock()
+	defer mu.Unlock()
+	status.Skipped++
+}
+
+func recordFailure(url string, err error) {
+	mu.Lock()
+	defer mu.Unlock()
+	if url != "" {
+		status.Failed++
+	}
+	if len(status.Failures) < maxFailures {
+		status.Failures = append(status.Failures, Failure{URL: url, Error: err.Error()})
+	}
+}
+
+func pause(until time.Time) {
+	mu.Lock()
+	defer mu.Unlock()
+	if until.After(status.PausedUntil) {
+		status.PausedUntil = until
+	}
+}
@@ -0,0 +1,20 @@
+package models
+
+import "time"
+
+// APIKey identifies a consumer of the document endpoints. The key itself is only shown once
+// when it is created; afterwards it is known by its prefix.
+type APIKey struct {
+	ID         int64      `json:"id"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`      // First characters of the key, to tell keys apart
+	DailyQuota int        `json:"daily_quota"` // Requests allowed per UTC day
+	RateLimit  int        `json:"rate_limit"`  // Requests allowed per minute
+	CreatedAt  time.Time  `json:"created_at"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+
+	// Usage counters, only filled in for the dashboard
+	RequestsToday int `json:"requests_today"`
+	RequestsWeek  int `json:"requests_week"` // Requests over the last seven days, today included
+}
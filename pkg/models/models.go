@@ -4,18 +4,67 @@ import "time"
 
 // MarkdownDocument represents the response model for the README content
 type MarkdownDocument struct {
-	Content string `json:"content"` // HTML content converted from Markdown
-	//RawContent string           `json:"rawContent"` // Original raw Markdown content
-	Metadata DocumentMetadata `json:"metadata"` // Metadata about the document
+	Content    string           `json:"content"`              // HTML content converted from Markdown
+	RawContent string           `json:"rawContent,omitempty"` // Original raw Markdown content, served by ?format=markdown
+	Metadata   DocumentMetadata `json:"metadata"`             // Metadata about the document
+	TOC        []TOCEntry       `json:"toc,omitempty"`        // Nested outline of the document headings
+	Stale      bool             `json:"stale,omitempty"`      // Last known good copy, served because the source could not be reached
+}
+
+// TOCEntry is a heading in the table of contents, with its nested subheadings
+type TOCEntry struct {
+	Level    int        `json:"level"`              // Heading level, 1 to 6
+	Text     string     `json:"text"`               // Plain text of the heading
+	ID       string     `json:"id"`                 // Anchor id of the heading in the HTML content
+	Children []TOCEntry `json:"children,omitempty"` // Subheadings
 }
 
 // DocumentMetadata holds the metadata for the document (e.g., title, repository)
 type DocumentMetadata struct {
-	Title       string    `json:"title"`       // Title of the document (e.g., "README - repo")
-	Repository  string    `json:"repository"`  // Repository name
-	LastUpdated time.Time `json:"lastUpdated"` // Timestamp of the last update
-	Author      string    `json:"author"`      // Author of the repository (owner)
-	Description string    `json:"description"` // Description or summary of the document
+	Title       string     `json:"title"`                 // Title of the document (e.g., "README - repo")
+	Repository  string     `json:"repository"`            // Repository name
+	LastUpdated *time.Time `json:"lastUpdated,omitempty"` // Timestamp of the last update, left out when it could not be fetched
+	Author      string     `json:"author"`                // Author of the repository (owner)
+	Description string     `json:"description"`           // Description or summary of the document
+	Partial     bool       `json:"partial,omitempty"`     // Some commit metadata could not be fetched and is left out
+
+	// Fields derived from the parsed document
+	Excerpt      string   `json:"excerpt"`          // Plain text of the first paragraph
+	WordCount    int      `json:"wordCount"`        // Words of prose, excluding code blocks
+	ReadingTime  int      `json:"readingTime"`      // Estimated reading time in minutes
+	HeadingCount int      `json:"headingCount"`     // Number of headings
+	Images       []string `json:"images,omitempty"` // Image URLs in document order
+
+	// Fields only set through the document's front matter
+	Date         *time.Time `json:"date,omitempty"`         // Publication date
+	Tags         []string   `json:"tags,omitempty"`         // Tags or keywords
+	CoverImage   string     `json:"coverImage,omitempty"`   // Cover image URL, resolved like images in the content
+	CanonicalURL string     `json:"canonicalUrl,omitempty"` // Canonical URL of the original publication
+	Draft        bool       `json:"draft,omitempty"`        // Whether the document is marked as a draft
+
+	// Fields only set when requested with ?history=N
+	Commits      []CommitInfo  `json:"commits,omitempty"`      // Last commits touching the document, newest first
+	Contributors []Contributor `json:"contributors,omitempty"` // Distinct authors of those commits, most recent first
+}
+
+// CommitInfo is a commit in the history of a document
+type CommitInfo struct {
+	SHA         string    `json:"sha"`
+	Message     string    `json:"message"`
+	Author      string    `json:"author"`                // Git author name
+	AuthorLogin string    `json:"authorLogin,omitempty"` // Account of the author on the content source
+	AvatarURL   string    `json:"avatarUrl,omitempty"`
+	Date        time.Time `json:"date"`
+	URL         string    `json:"url,omitempty"` // Web page of the commit
+}
+
+// Contributor is an author in the history of a document
+type Contributor struct {
+	Name       string `json:"name"`
+	Login      string `json:"login,omitempty"`
+	AvatarURL  string `json:"avatarUrl,omitempty"`
+	ProfileURL string `json:"profileUrl,omitempty"`
+	Commits    int    `json:"commits"` // Commits by the contributor within the requested history
 }
 
 type RepoListItem struct {
@@ -31,3 +80,47 @@ type RepoListItem struct {
 type RepoListResponse struct {
 	Repos []RepoListItem `json:"repos"`
 }
+
+// DocumentDiff is the response model of /md/diff
+type DocumentDiff struct {
+	URL         string       `json:"url"`         // Document URL as requested
+	From        string       `json:"from"`        // Ref the diff starts from
+	To          string       `json:"to"`          // Ref the diff ends at
+	Granularity string       `json:"granularity"` // "block" or "line"
+	Content     string       `json:"content"`     // Rendered diff as HTML
+	Stats       DiffStats    `json:"stats"`
+	Changes     []DiffChange `json:"changes"` // Every block or line of both refs in diff order
+}
+
+// DiffStats counts the blocks or lines of a diff by kind
+type DiffStats struct {
+	Inserted  int `json:"inserted"`
+	Deleted   int `json:"deleted"`
+	Unchanged int `json:"unchanged"`
+}
+
+// DiffChange is a block or line of a diff
+type DiffChange struct {
+	Op   string `json:"op"`   // "equal", "insert" or "delete"
+	Text string `json:"text"` // Markdown of the block or line
+}
+
+// DocsSite is the response model of /docs: the navigation of a documentation folder
+// and, when a page is requested, that page with its neighbours
+type DocsSite struct {
+	URL       string            `json:"url"`   // Folder URL as requested
+	Title     string            `json:"title"` // Repository name
+	Nav       []DocsNavItem     `json:"nav"`
+	Truncated bool              `json:"truncated,omitempty"` // Only part of the folder is listed
+	Page      *MarkdownDocument `json:"page,omitempty"`
+	Prev      *DocsNavItem      `json:"prev,omitempty"`
+	Next      *DocsNavItem      `json:"next,omitempty"`
+}
+
+// DocsNavItem is a page or folder in the navigation of a documentation site
+type DocsNavItem struct {
+	Title    string        `json:"title"`
+	Path     string        `json:"path,omitempty"` // Page path relative to the docs folder; empty for folders without an index page
+	URL      string        `json:"url,omitempty"`  // Document URL accepted by /md
+	Children []DocsNavItem `json:"children,omitempty"`
+}